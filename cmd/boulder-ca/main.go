@@ -150,7 +150,17 @@ func main() {
 	cmd.FailOnError(c.PA.CheckChallenges(), "Invalid PA configuration")
 	cmd.FailOnError(c.PA.CheckIdentifiers(), "Invalid PA configuration")
 
-	pa, err := policy.New(c.PA.Identifiers, c.PA.Challenges, logger)
+	pa, err := policy.New(
+		c.PA.Identifiers,
+		c.PA.Challenges,
+		logger,
+		policy.WithReservedPrefixes(c.PA.AdditionalReservedPrefixes),
+		policy.WithAllowedScripts(c.PA.AllowedIDNScripts),
+		policy.WithLeadingUnderscoreLabels(c.PA.AllowLeadingUnderscoreLabels),
+		policy.WithDecisionCache(c.PA.DecisionCacheSize),
+		policy.WithMaxDNSNameLength(c.PA.MaxDNSNameLength),
+		policy.WithMaxLabels(c.PA.MaxLabels),
+	)
 	cmd.FailOnError(err, "Couldn't create PA")
 
 	if c.CA.HostnamePolicyFile == "" {
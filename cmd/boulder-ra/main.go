@@ -66,6 +66,19 @@ type Config struct {
 			// necessary in the RA.
 			Defaults string `validate:"required_with=Redis"`
 
+			// ExpandEnvInDefaults causes ${VAR} references in the Defaults
+			// file to be replaced with the values of the corresponding
+			// environment variables before the file is parsed. Any other '$',
+			// including a bare $VAR, is left untouched.
+			ExpandEnvInDefaults bool
+
+			// EmissionIntervalRounding determines how each limit's emission
+			// interval (period / count) is rounded to whole nanoseconds:
+			// "truncate" (the default) may very slightly exceed the configured
+			// rate, while "ceil" never exceeds it. It should match across all
+			// components which share rate limit buckets.
+			EmissionIntervalRounding ratelimits.RoundingMode `validate:"omitempty,oneof=truncate ceil"`
+
 			// Overrides is a path to a YAML file containing overrides for the
 			// default rate limits. See: ratelimits/README.md for details. If
 			// neither this field nor OverridesFromDB is set, all requesters
@@ -76,6 +89,12 @@ type Config struct {
 			// necessary in the RA.
 			Overrides string
 
+			// OverridesAllowDuplicates causes an entry in the Overrides file
+			// to replace an earlier entry for the same bucket key, rather than
+			// the file failing to load. It exists only for backward
+			// compatibility with existing overrides files.
+			OverridesAllowDuplicates bool
+
 			// OverridesFromDB causes the WFE and RA to retrieve rate limit overrides
 			// from the database, instead of from a file.
 			OverridesFromDB bool
@@ -159,7 +178,19 @@ func main() {
 	cmd.FailOnError(c.PA.CheckChallenges(), "Invalid PA configuration")
 	cmd.FailOnError(c.PA.CheckIdentifiers(), "Invalid PA configuration")
 
-	pa, err := policy.New(c.PA.Identifiers, c.PA.Challenges, logger)
+	pa, err := policy.New(
+		c.PA.Identifiers,
+		c.PA.Challenges,
+		logger,
+		policy.WithReservedPrefixes(c.PA.AdditionalReservedPrefixes),
+		policy.WithSingleIdentifierTypeProfiles(c.PA.SingleIdentifierTypeProfiles),
+		policy.WithAllowedScripts(c.PA.AllowedIDNScripts),
+		policy.WithLeadingUnderscoreLabels(c.PA.AllowLeadingUnderscoreLabels),
+		policy.WithDecisionCache(c.PA.DecisionCacheSize),
+		policy.WithMaxDNSNameLength(c.PA.MaxDNSNameLength),
+		policy.WithMaxLabels(c.PA.MaxLabels),
+		policy.WithMaxSubErrors(c.PA.MaxSubErrors),
+	)
 	cmd.FailOnError(err, "Couldn't create PA")
 
 	if features.Get().DNSAccount01Enabled != pa.ChallengeTypeEnabled(core.ChallengeTypeDNSAccount01) {
@@ -270,9 +301,9 @@ func main() {
 				cmd.Fail("OverridesFromDB and an overrides file were both defined, but are mutually exclusive")
 			}
 			saroc := sapb.NewStorageAuthorityReadOnlyClient(saConn)
-			txnBuilder, err = ratelimits.NewTransactionBuilderFromDatabase(c.RA.Limiter.Defaults, saroc.GetEnabledRateLimitOverrides, scope, logger)
+			txnBuilder, err = ratelimits.NewTransactionBuilderFromDatabase(c.RA.Limiter.Defaults, c.RA.Limiter.ExpandEnvInDefaults, c.RA.Limiter.EmissionIntervalRounding, saroc.GetEnabledRateLimitOverrides, scope, logger)
 		} else {
-			txnBuilder, err = ratelimits.NewTransactionBuilderFromFiles(c.RA.Limiter.Defaults, c.RA.Limiter.Overrides, scope, logger)
+			txnBuilder, err = ratelimits.NewTransactionBuilderFromFiles(c.RA.Limiter.Defaults, c.RA.Limiter.ExpandEnvInDefaults, c.RA.Limiter.EmissionIntervalRounding, c.RA.Limiter.Overrides, c.RA.Limiter.OverridesAllowDuplicates, scope, logger)
 		}
 		cmd.FailOnError(err, "Failed to create rate limits transaction builder")
 
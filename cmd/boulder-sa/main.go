@@ -11,6 +11,7 @@ import (
 	"github.com/letsencrypt/boulder/config"
 	"github.com/letsencrypt/boulder/db"
 	"github.com/letsencrypt/boulder/features"
+	"github.com/letsencrypt/boulder/goodkey"
 	bgrpc "github.com/letsencrypt/boulder/grpc"
 	"github.com/letsencrypt/boulder/sa"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
@@ -31,6 +32,17 @@ type Config struct {
 		// LagFactor is how long to sleep before retrying a read request that may
 		// have failed solely due to replication lag.
 		LagFactor config.Duration `validate:"-"`
+
+		// ReplacementGracePeriod is how long after a certificate is first
+		// replaced that a new replacement order for it will still be accepted.
+		// It only has an effect if the StoreReplacementFinalizedTime feature is
+		// enabled. If zero, a certificate can only be replaced once.
+		ReplacementGracePeriod config.Duration `validate:"-"`
+
+		// AllowedRegistrationKeys restricts the account key types and sizes
+		// which may be stored for new registrations. If nil, defaults to the
+		// keys allowed by the Let's Encrypt CPS.
+		AllowedRegistrationKeys *goodkey.AllowedKeys `validate:"-"`
 	}
 
 	Syslog        cmd.SyslogConfig
@@ -91,10 +103,10 @@ func main() {
 	cmd.FailOnError(err, "TLS config")
 
 	saroi, err := sa.NewSQLStorageAuthorityRO(
-		dbReadOnlyMap, dbIncidentsMap, scope, c.SA.LagFactor.Duration, clk, logger)
+		dbReadOnlyMap, dbIncidentsMap, scope, c.SA.LagFactor.Duration, c.SA.ReplacementGracePeriod.Duration, clk, logger)
 	cmd.FailOnError(err, "Failed to create read-only SA impl")
 
-	sai, err := sa.NewSQLStorageAuthorityWrapping(saroi, dbMap, scope)
+	sai, err := sa.NewSQLStorageAuthorityWrapping(saroi, dbMap, c.SA.AllowedRegistrationKeys, scope)
 	cmd.FailOnError(err, "Failed to create SA impl")
 
 	var saai *sa.SQLStorageAuthorityAdmin
@@ -168,6 +168,19 @@ type Config struct {
 			// in this file must be identical to those in the RA.
 			Defaults string `validate:"required_with=Redis"`
 
+			// ExpandEnvInDefaults causes ${VAR} references in the Defaults
+			// file to be replaced with the values of the corresponding
+			// environment variables before the file is parsed. Any other '$',
+			// including a bare $VAR, is left untouched.
+			ExpandEnvInDefaults bool
+
+			// EmissionIntervalRounding determines how each limit's emission
+			// interval (period / count) is rounded to whole nanoseconds:
+			// "truncate" (the default) may very slightly exceed the configured
+			// rate, while "ceil" never exceeds it. It should match across all
+			// components which share rate limit buckets.
+			EmissionIntervalRounding ratelimits.RoundingMode `validate:"omitempty,oneof=truncate ceil"`
+
 			// Overrides is a path to a YAML file containing overrides for the
 			// default rate limits. See: ratelimits/README.md for details. If
 			// neither this field nor OverridesFromDB is set, all requesters
@@ -176,6 +189,12 @@ type Config struct {
 			// identical to those in the RA.
 			Overrides string
 
+			// OverridesAllowDuplicates causes an entry in the Overrides file
+			// to replace an earlier entry for the same bucket key, rather than
+			// the file failing to load. It exists only for backward
+			// compatibility with existing overrides files.
+			OverridesAllowDuplicates bool
+
 			// OverridesFromDB causes the WFE and RA to retrieve rate limit
 			// overrides from the database, instead of from a file.
 			OverridesFromDB bool
@@ -430,9 +449,9 @@ func main() {
 			if c.WFE.Limiter.Overrides != "" {
 				cmd.Fail("OverridesFromDB and an overrides file were both defined, but are mutually exclusive")
 			}
-			txnBuilder, err = ratelimits.NewTransactionBuilderFromDatabase(c.WFE.Limiter.Defaults, sac.GetEnabledRateLimitOverrides, stats, logger)
+			txnBuilder, err = ratelimits.NewTransactionBuilderFromDatabase(c.WFE.Limiter.Defaults, c.WFE.Limiter.ExpandEnvInDefaults, c.WFE.Limiter.EmissionIntervalRounding, sac.GetEnabledRateLimitOverrides, stats, logger)
 		} else {
-			txnBuilder, err = ratelimits.NewTransactionBuilderFromFiles(c.WFE.Limiter.Defaults, c.WFE.Limiter.Overrides, stats, logger)
+			txnBuilder, err = ratelimits.NewTransactionBuilderFromFiles(c.WFE.Limiter.Defaults, c.WFE.Limiter.ExpandEnvInDefaults, c.WFE.Limiter.EmissionIntervalRounding, c.WFE.Limiter.Overrides, c.WFE.Limiter.OverridesAllowDuplicates, stats, logger)
 		}
 		cmd.FailOnError(err, "Failed to create rate limits transaction builder")
 
@@ -686,7 +686,15 @@ func main() {
 	saDbMap, err := sa.InitWrappedDb(config.CertChecker.DB, prometheus.DefaultRegisterer, logger)
 	cmd.FailOnError(err, "While initializing dbMap")
 
-	pa, err := policy.New(config.PA.Identifiers, config.PA.Challenges, logger)
+	pa, err := policy.New(
+		config.PA.Identifiers,
+		config.PA.Challenges,
+		logger,
+		policy.WithReservedPrefixes(config.PA.AdditionalReservedPrefixes),
+		policy.WithLeadingUnderscoreLabels(config.PA.AllowLeadingUnderscoreLabels),
+		policy.WithMaxDNSNameLength(config.PA.MaxDNSNameLength),
+		policy.WithMaxLabels(config.PA.MaxLabels),
+	)
 	cmd.FailOnError(err, "Failed to create PA")
 
 	err = pa.LoadIdentPolicyFile(config.CertChecker.HostnamePolicyFile)
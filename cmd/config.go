@@ -96,6 +96,47 @@ type PAConfig struct {
 	DBConfig    `validate:"-"`
 	Challenges  map[core.AcmeChallenge]bool        `validate:"omitempty,dive,keys,oneof=http-01 dns-01 tls-alpn-01 dns-account-01 dns-persist-01,endkeys"`
 	Identifiers map[identifier.IdentifierType]bool `validate:"omitempty,dive,keys,oneof=dns ip,endkeys"`
+
+	// AdditionalReservedPrefixes is a list of IP address prefixes, in CIDR
+	// notation, which should be treated as reserved in addition to the IANA
+	// special-purpose address registries compiled into Boulder.
+	AdditionalReservedPrefixes []string `validate:"omitempty,dive,cidr"`
+
+	// SingleIdentifierTypeProfiles is a list of certificate profile names for
+	// which every identifier in an order must be of the same type. Orders
+	// mixing, for example, DNS and IP identifiers are rejected for these
+	// profiles.
+	SingleIdentifierTypeProfiles []string `validate:"omitempty,dive,alphanum,min=1,max=32"`
+
+	// AllowedIDNScripts is a list of Unicode script names (e.g. "Latin",
+	// "Cyrillic"). If non-empty, each label of an internationalized domain name
+	// must use characters from exactly one of these scripts, in addition to
+	// characters common to all scripts. If empty, no restriction is applied.
+	AllowedIDNScripts []string `validate:"omitempty,dive,min=1"`
+
+	// AllowLeadingUnderscoreLabels permits DNS identifiers whose leftmost label
+	// begins with an underscore (e.g. "_dmarc.example.com"). These are not
+	// valid hostnames, so this MUST NOT be set for a publicly-trusted CA.
+	AllowLeadingUnderscoreLabels bool
+
+	// DecisionCacheSize is the maximum number of identifiers permitted by
+	// the identifier policy to cache. Rejections are not cached. Cached
+	// identifiers are invalidated when a changed identifier policy file is
+	// loaded. If zero, no identifiers are cached.
+	DecisionCacheSize int `validate:"omitempty,min=0"`
+
+	// MaxDNSNameLength is the maximum length, in bytes, of a DNS identifier.
+	// It may not exceed the 253 bytes permitted by the DNS. If zero, that
+	// limit is used.
+	MaxDNSNameLength int `validate:"omitempty,min=1,max=253"`
+
+	// MaxLabels is the maximum number of labels in a DNS identifier. If zero,
+	// the default of 10 is used.
+	MaxLabels int `validate:"omitempty,min=2,max=127"`
+
+	// MaxSubErrors is the maximum number of per-identifier sub-problems
+	// included when rejecting an order. If zero, the default of 100 is used.
+	MaxSubErrors int `validate:"omitempty,min=1"`
 }
 
 // CheckChallenges checks whether the list of challenges in the PA config
@@ -78,6 +78,19 @@ type Config struct {
 			// that limit will be disabled. Failed Authorizations limits passed
 			// in this file must be identical to those in the RA.
 			Defaults string `validate:"required_with=Redis"`
+
+			// ExpandEnvInDefaults causes ${VAR} references in the Defaults
+			// file to be replaced with the values of the corresponding
+			// environment variables before the file is parsed. Any other '$',
+			// including a bare $VAR, is left untouched.
+			ExpandEnvInDefaults bool
+
+			// EmissionIntervalRounding determines how each limit's emission
+			// interval (period / count) is rounded to whole nanoseconds:
+			// "truncate" (the default) may very slightly exceed the configured
+			// rate, while "ceil" never exceeds it. It should match across all
+			// components which share rate limit buckets.
+			EmissionIntervalRounding ratelimits.RoundingMode `validate:"omitempty,oneof=truncate ceil"`
 		}
 
 		// OverridesImporter configures the periodic import of approved rate
@@ -223,7 +236,7 @@ func main() {
 		source := ratelimits.NewRedisSource(limiterRedis.Ring, clk, stats)
 		limiter, err = ratelimits.NewLimiter(clk, source, stats)
 		cmd.FailOnError(err, "Failed to create rate limiter")
-		txnBuilder, err = ratelimits.NewTransactionBuilderFromFiles(c.SFE.Limiter.Defaults, "", stats, logger)
+		txnBuilder, err = ratelimits.NewTransactionBuilderFromFiles(c.SFE.Limiter.Defaults, c.SFE.Limiter.ExpandEnvInDefaults, c.SFE.Limiter.EmissionIntervalRounding, "", false, stats, logger)
 		cmd.FailOnError(err, "Failed to create rate limits transaction builder")
 	}
 
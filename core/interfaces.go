@@ -8,6 +8,7 @@ import (
 // TODO(#5891): Move this interface to a more appropriate location.
 type PolicyAuthority interface {
 	WillingToIssue(identifier.ACMEIdentifiers) error
+	CheckProfileIdentifierTypes(string, []identifier.IdentifierType, identifier.ACMEIdentifiers) error
 	ChallengeTypesFor(identifier.ACMEIdentifier) ([]AcmeChallenge, error)
 	ChallengeTypeEnabled(AcmeChallenge) bool
 	CheckAuthzChallenges(*Authorization) error
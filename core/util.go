@@ -1,6 +1,7 @@
 package core
 
 import (
+	"bytes"
 	"context"
 	"crypto"
 	"crypto/ecdsa"
@@ -17,6 +18,7 @@ import (
 	"io"
 	"math/big"
 	mrand "math/rand/v2"
+	"net/netip"
 	"os"
 	"path"
 	"reflect"
@@ -347,6 +349,48 @@ func HashIdentifiers(idents identifier.ACMEIdentifiers) []byte {
 	return hash[:]
 }
 
+// FQDNSetHashMatches returns true if the provided hash is the result of calling
+// HashIdentifiers on the provided identifiers. Because HashIdentifiers
+// normalizes its input, the order and case of the identifiers do not matter.
+func FQDNSetHashMatches(hash []byte, idents identifier.ACMEIdentifiers) bool {
+	return bytes.Equal(hash, HashIdentifiers(idents))
+}
+
+// SameFQDNSet returns true if a and b contain the same set of identifiers,
+// ignoring order and duplicates. DNS identifier values are compared
+// case-insensitively and IP address identifier values are compared in their
+// canonical form. Unlike FQDNSetHashMatches, this does not depend on
+// HashIdentifiers, and neither input is modified.
+func SameFQDNSet(a, b identifier.ACMEIdentifiers) bool {
+	toSet := func(idents identifier.ACMEIdentifiers) map[identifier.ACMEIdentifier]struct{} {
+		set := make(map[identifier.ACMEIdentifier]struct{}, len(idents))
+		for _, ident := range idents {
+			switch ident.Type {
+			case identifier.TypeDNS:
+				ident.Value = strings.ToLower(ident.Value)
+			case identifier.TypeIP:
+				ip, err := netip.ParseAddr(ident.Value)
+				if err == nil {
+					ident.Value = ip.String()
+				}
+			}
+			set[ident] = struct{}{}
+		}
+		return set
+	}
+	setA, setB := toSet(a), toSet(b)
+	if len(setA) != len(setB) {
+		return false
+	}
+	for ident := range setA {
+		_, ok := setB[ident]
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
 // LoadCert loads a PEM certificate specified by filename or returns an error
 func LoadCert(filename string) (*x509.Certificate, error) {
 	certPEM, err := os.ReadFile(filename)
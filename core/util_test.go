@@ -455,6 +455,73 @@ func TestHashIdentifiers(t *testing.T) {
 	}
 }
 
+func TestFQDNSetHashMatches(t *testing.T) {
+	t.Parallel()
+
+	idents := identifier.ACMEIdentifiers{
+		identifier.NewDNS("example.com"),
+		identifier.NewDNS("www.example.com"),
+		identifier.NewIP(netip.MustParseAddr("3fff::")),
+	}
+	hash := HashIdentifiers(idents)
+
+	if !FQDNSetHashMatches(hash, idents) {
+		t.Errorf("Expected hash to match the identifiers it was computed from")
+	}
+
+	reordered := identifier.ACMEIdentifiers{idents[2], idents[0], idents[1]}
+	if !FQDNSetHashMatches(hash, reordered) {
+		t.Errorf("Expected hash to match reordered identifiers %#v", reordered)
+	}
+
+	different := identifier.ACMEIdentifiers{idents[0], idents[1]}
+	if FQDNSetHashMatches(hash, different) {
+		t.Errorf("Expected hash not to match a different set of identifiers %#v", different)
+	}
+
+	if FQDNSetHashMatches(nil, idents) {
+		t.Errorf("Expected nil hash not to match")
+	}
+}
+
+func TestSameFQDNSet(t *testing.T) {
+	t.Parallel()
+
+	idents := identifier.ACMEIdentifiers{
+		identifier.NewDNS("example.com"),
+		identifier.NewDNS("www.example.com"),
+		identifier.NewIP(netip.MustParseAddr("3fff::1")),
+	}
+
+	reordered := identifier.ACMEIdentifiers{
+		{Type: identifier.TypeIP, Value: "3fff:0:0::1"},
+		identifier.NewDNS("WWW.example.com"),
+		identifier.NewDNS("example.com"),
+	}
+	if !SameFQDNSet(idents, reordered) {
+		t.Errorf("Expected reordered identifiers %#v to be the same set", reordered)
+	}
+
+	duplicated := append(slices.Clone(idents), identifier.NewDNS("example.com"), identifier.NewDNS("Example.com"))
+	if !SameFQDNSet(idents, duplicated) {
+		t.Errorf("Expected identifiers with duplicates %#v to be the same set", duplicated)
+	}
+
+	different := identifier.ACMEIdentifiers{idents[0], idents[1], identifier.NewDNS("mail.example.com")}
+	if SameFQDNSet(idents, different) {
+		t.Errorf("Expected %#v not to be the same set", different)
+	}
+
+	subset := identifier.ACMEIdentifiers{idents[0], idents[1]}
+	if SameFQDNSet(idents, subset) {
+		t.Errorf("Expected subset %#v not to be the same set", subset)
+	}
+
+	if reordered[1].Value != "WWW.example.com" {
+		t.Errorf("SameFQDNSet should not modify its input")
+	}
+}
+
 func TestIsCanceled(t *testing.T) {
 	if !IsCanceled(context.Canceled) {
 		t.Errorf("Expected context.Canceled to be canceled, but wasn't.")
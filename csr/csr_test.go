@@ -36,6 +36,10 @@ func (pa *mockPA) WillingToIssue(idents identifier.ACMEIdentifiers) error {
 	return nil
 }
 
+func (pa *mockPA) CheckProfileIdentifierTypes(profile string, permitted []identifier.IdentifierType, idents identifier.ACMEIdentifiers) error {
+	return nil
+}
+
 func (pa *mockPA) ChallengeTypeEnabled(t core.AcmeChallenge) bool {
 	return true
 }
@@ -71,6 +71,8 @@ const (
 	BadSignatureAlgorithm
 	AccountDoesNotExist
 	BadNonce
+	// The account acting on the request has been deactivated.
+	AccountDeactivated
 )
 
 func (ErrorType) Error() string {
@@ -86,6 +88,16 @@ type BoulderError struct {
 	// RetryAfter the duration a client should wait before retrying the request
 	// which resulted in this error.
 	RetryAfter time.Duration
+
+	// LimitName is the name of the rate limit which resulted in this error, as
+	// returned by ratelimits.Name.String(). It is only populated for RateLimit
+	// errors created by one of the limit-specific constructors below.
+	LimitName string
+
+	// Anticipatory is true for RateLimit errors which indicate that the
+	// request would have exceeded a limit had it been allowed to proceed,
+	// rather than that the limit has already been exceeded.
+	Anticipatory bool
 }
 
 // SubBoulderError represents sub-errors specific to an identifier that are
@@ -152,21 +164,51 @@ func (be *BoulderError) GRPCStatus() *status.Status {
 		c = codes.InvalidArgument
 	case UnsupportedContact:
 		c = codes.InvalidArgument
+	case AccountDeactivated:
+		c = codes.PermissionDenied
 	default:
 		c = codes.Unknown
 	}
 	return status.New(c, be.Error())
 }
 
+// RateLimitName returns the name of the rate limit which resulted in this
+// error, or the empty string if the error was not caused by a specific limit.
+func (be *BoulderError) RateLimitName() string {
+	return be.LimitName
+}
+
+// IsAnticipatory returns true if this error indicates that the request would
+// have exceeded a rate limit, rather than that the limit is already exceeded.
+func (be *BoulderError) IsAnticipatory() bool {
+	return be.Anticipatory
+}
+
 // WithSubErrors returns a new BoulderError instance created by adding the
 // provided subErrs to the existing BoulderError.
 func (be *BoulderError) WithSubErrors(subErrs []SubBoulderError) *BoulderError {
 	return &BoulderError{
-		Type:       be.Type,
-		Detail:     be.Detail,
-		SubErrors:  append(be.SubErrors, subErrs...),
-		RetryAfter: be.RetryAfter,
+		Type:         be.Type,
+		Detail:       be.Detail,
+		SubErrors:    append(be.SubErrors, subErrs...),
+		RetryAfter:   be.RetryAfter,
+		LimitName:    be.LimitName,
+		Anticipatory: be.Anticipatory,
+	}
+}
+
+// WithMaxRetryAfter is like WithSubErrors, but additionally sets the RetryAfter
+// of the returned error to the largest RetryAfter of the receiver and the
+// provided suberrors, so that clients back off for the longest applicable
+// period.
+func (be *BoulderError) WithMaxRetryAfter(subErrs []SubBoulderError) *BoulderError {
+	out := be.WithSubErrors(subErrs)
+	for _, subErr := range subErrs {
+		if subErr.BoulderError != nil && subErr.RetryAfter > out.RetryAfter {
+			out.RetryAfter = subErr.RetryAfter
+		}
 	}
+	return out
 }
 
 // New is a convenience function for creating a new BoulderError.
@@ -210,59 +252,77 @@ func RateLimitError(retryAfter time.Duration, msg string, args ...any) error {
 	}
 }
 
-func RegistrationsPerIPAddressError(retryAfter time.Duration, msg string, args ...any) error {
+// AnticipatoryRateLimitError is like RateLimitError, but for use when a limit
+// is checked speculatively and the request would put the client over it.
+func AnticipatoryRateLimitError(retryAfter time.Duration, msg string, args ...any) error {
+	return &BoulderError{
+		Type:         RateLimit,
+		Detail:       fmt.Sprintf(msg+": see https://letsencrypt.org/docs/rate-limits/", args...),
+		RetryAfter:   retryAfter,
+		Anticipatory: true,
+	}
+}
+
+func RegistrationsPerIPAddressError(limitName string, retryAfter time.Duration, msg string, args ...any) error {
 	return &BoulderError{
 		Type:       RateLimit,
 		Detail:     fmt.Sprintf(msg+": see https://letsencrypt.org/docs/rate-limits/#new-registrations-per-ip-address", args...),
 		RetryAfter: retryAfter,
+		LimitName:  limitName,
 	}
 }
 
-func RegistrationsPerIPv6RangeError(retryAfter time.Duration, msg string, args ...any) error {
+func RegistrationsPerIPv6RangeError(limitName string, retryAfter time.Duration, msg string, args ...any) error {
 	return &BoulderError{
 		Type:       RateLimit,
 		Detail:     fmt.Sprintf(msg+": see https://letsencrypt.org/docs/rate-limits/#new-registrations-per-ipv6-range", args...),
 		RetryAfter: retryAfter,
+		LimitName:  limitName,
 	}
 }
 
-func NewOrdersPerAccountError(retryAfter time.Duration, msg string, args ...any) error {
+func NewOrdersPerAccountError(limitName string, retryAfter time.Duration, msg string, args ...any) error {
 	return &BoulderError{
 		Type:       RateLimit,
 		Detail:     fmt.Sprintf(msg+": see https://letsencrypt.org/docs/rate-limits/#new-orders-per-account", args...),
 		RetryAfter: retryAfter,
+		LimitName:  limitName,
 	}
 }
 
-func CertificatesPerDomainError(retryAfter time.Duration, msg string, args ...any) error {
+func CertificatesPerDomainError(limitName string, retryAfter time.Duration, msg string, args ...any) error {
 	return &BoulderError{
 		Type:       RateLimit,
 		Detail:     fmt.Sprintf(msg+": see https://letsencrypt.org/docs/rate-limits/#new-certificates-per-registered-domain", args...),
 		RetryAfter: retryAfter,
+		LimitName:  limitName,
 	}
 }
 
-func CertificatesPerFQDNSetError(retryAfter time.Duration, msg string, args ...any) error {
+func CertificatesPerFQDNSetError(limitName string, retryAfter time.Duration, msg string, args ...any) error {
 	return &BoulderError{
 		Type:       RateLimit,
 		Detail:     fmt.Sprintf(msg+": see https://letsencrypt.org/docs/rate-limits/#new-certificates-per-exact-set-of-identifiers", args...),
 		RetryAfter: retryAfter,
+		LimitName:  limitName,
 	}
 }
 
-func FailedAuthorizationsPerDomainPerAccountError(retryAfter time.Duration, msg string, args ...any) error {
+func FailedAuthorizationsPerDomainPerAccountError(limitName string, retryAfter time.Duration, msg string, args ...any) error {
 	return &BoulderError{
 		Type:       RateLimit,
 		Detail:     fmt.Sprintf(msg+": see https://letsencrypt.org/docs/rate-limits/#authorization-failures-per-identifier-per-account", args...),
 		RetryAfter: retryAfter,
+		LimitName:  limitName,
 	}
 }
 
-func LimitOverrideRequestsPerIPAddressError(retryAfter time.Duration, msg string, args ...any) error {
+func LimitOverrideRequestsPerIPAddressError(limitName string, retryAfter time.Duration, msg string, args ...any) error {
 	return &BoulderError{
 		Type:       RateLimit,
 		Detail:     fmt.Sprintf(msg+": see https://letsencrypt.org/docs/rate-limits/#new-registrations-per-ip-address", args...),
 		RetryAfter: retryAfter,
+		LimitName:  limitName,
 	}
 }
 
@@ -345,3 +405,7 @@ func AccountDoesNotExistError(msg string, args ...any) error {
 func BadNonceError(msg string, args ...any) error {
 	return newf(BadNonce, msg, args...)
 }
+
+func AccountDeactivatedError(msg string, args ...any) error {
+	return newf(AccountDeactivated, msg, args...)
+}
@@ -2,6 +2,9 @@ package errors
 
 import (
 	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
 
 	"github.com/letsencrypt/boulder/identifier"
 	"github.com/letsencrypt/boulder/test"
@@ -48,3 +51,84 @@ func TestWithSubErrors(t *testing.T) {
 	outResult = outResult.WithSubErrors([]SubBoulderError{anotherSubErr})
 	test.AssertDeepEquals(t, outResult.SubErrors, append(subErrs, anotherSubErr))
 }
+
+func TestWithMaxRetryAfter(t *testing.T) {
+	rateLimitSubErr := func(value string, retryAfter time.Duration) SubBoulderError {
+		return SubBoulderError{
+			Identifier: identifier.NewDNS(value),
+			BoulderError: &BoulderError{
+				Type:       RateLimit,
+				Detail:     "too many certificates",
+				RetryAfter: retryAfter,
+			},
+		}
+	}
+
+	topErr := &BoulderError{Type: RateLimit, Detail: "too many certificates"}
+	subErrs := []SubBoulderError{
+		rateLimitSubErr("a.example.com", time.Minute),
+		rateLimitSubErr("b.example.com", 0),
+		rateLimitSubErr("c.example.com", time.Hour),
+	}
+	outResult := topErr.WithMaxRetryAfter(subErrs)
+	test.AssertEquals(t, outResult.RetryAfter, time.Hour)
+	test.AssertDeepEquals(t, outResult.SubErrors, subErrs)
+	// The receiver should be unchanged.
+	test.AssertEquals(t, topErr.RetryAfter, time.Duration(0))
+
+	// A parent RetryAfter larger than any suberror's is kept.
+	topErr.RetryAfter = 2 * time.Hour
+	outResult = topErr.WithMaxRetryAfter(subErrs)
+	test.AssertEquals(t, outResult.RetryAfter, 2*time.Hour)
+
+	// Suberrors without a RetryAfter leave the parent's as-is.
+	topErr.RetryAfter = 0
+	outResult = topErr.WithMaxRetryAfter([]SubBoulderError{rateLimitSubErr("d.example.com", 0)})
+	test.AssertEquals(t, outResult.RetryAfter, time.Duration(0))
+}
+
+// TestRateLimitName tests that each limit-specific rate limit constructor
+// records the provided limit name, and that it survives WithSubErrors.
+func TestRateLimitName(t *testing.T) {
+	const name = "ExampleLimit"
+	testCases := []struct {
+		err      error
+		expected string
+	}{
+		{RateLimitError(time.Second, "generic"), ""},
+		{RegistrationsPerIPAddressError(name, time.Second, "test"), name},
+		{RegistrationsPerIPv6RangeError(name, time.Second, "test"), name},
+		{NewOrdersPerAccountError(name, time.Second, "test"), name},
+		{CertificatesPerDomainError(name, time.Second, "test"), name},
+		{CertificatesPerFQDNSetError(name, time.Second, "test"), name},
+		{FailedAuthorizationsPerDomainPerAccountError(name, time.Second, "test"), name},
+		{LimitOverrideRequestsPerIPAddressError(name, time.Second, "test"), name},
+	}
+	for _, tc := range testCases {
+		be, ok := tc.err.(*BoulderError)
+		test.Assert(t, ok, "expected a *BoulderError")
+		test.AssertEquals(t, be.RateLimitName(), tc.expected)
+		test.AssertEquals(t, be.WithSubErrors(nil).RateLimitName(), tc.expected)
+	}
+}
+
+func TestAnticipatory(t *testing.T) {
+	be, ok := AnticipatoryRateLimitError(time.Second, "would be over").(*BoulderError)
+	test.Assert(t, ok, "expected a *BoulderError")
+	test.AssertErrorIs(t, be, RateLimit)
+	test.Assert(t, be.IsAnticipatory(), "expected anticipatory error")
+	test.Assert(t, be.WithSubErrors(nil).IsAnticipatory(), "WithSubErrors should preserve Anticipatory")
+
+	be, ok = RateLimitError(time.Second, "over").(*BoulderError)
+	test.Assert(t, ok, "expected a *BoulderError")
+	test.Assert(t, !be.IsAnticipatory(), "expected non-anticipatory error")
+}
+
+func TestAccountDeactivatedGRPCStatus(t *testing.T) {
+	err := AccountDeactivatedError("account %d is deactivated", 1337)
+	test.AssertErrorIs(t, err, AccountDeactivated)
+	be, ok := err.(*BoulderError)
+	test.Assert(t, ok, "expected a BoulderError")
+	test.AssertEquals(t, be.GRPCStatus().Code(), codes.PermissionDenied)
+	test.AssertEquals(t, be.Detail, "account 1337 is deactivated")
+}
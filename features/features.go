@@ -79,6 +79,12 @@ type Config struct {
 	// unnecessary work due to parallel validations, but requires a database
 	// change to work.
 	SetAuthzProcessing bool
+
+	// StoreReplacementFinalizedTime causes the SA to record when a replacement
+	// order is finalized, so that the replaced certificate may be replaced
+	// again during a configurable grace period. This requires a database
+	// change to work.
+	StoreReplacementFinalizedTime bool
 }
 
 var fMu = new(sync.RWMutex)
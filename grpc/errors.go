@@ -51,6 +51,18 @@ func wrapError(ctx context.Context, appErr error) error {
 			pairs = append(pairs, "retryafter", berr.RetryAfter.String())
 		}
 
+		// If the error was caused by a specific rate limit then extend the
+		// metadata pairs to include the name of that limit.
+		if berr.LimitName != "" {
+			pairs = append(pairs, "limitname", berr.LimitName)
+		}
+
+		// If the error was anticipatory then extend the metadata pairs to
+		// say so.
+		if berr.Anticipatory {
+			pairs = append(pairs, "anticipatory", "true")
+		}
+
 		err := grpc.SetTrailer(ctx, metadata.Pairs(pairs...))
 		if err != nil {
 			return berrors.InternalServerError(
@@ -150,5 +162,18 @@ func unwrapError(err error, md metadata.MD) error {
 			)
 		}
 	}
+
+	limitNameVal, ok := md["limitname"]
+	if ok {
+		if len(limitNameVal) != 1 {
+			return berrors.InternalServerError(
+				"multiple 'limitname' in metadata, wrapped error %q",
+				inErrMsg,
+			)
+		}
+		outErr.LimitName = limitNameVal[0]
+	}
+
+	_, outErr.Anticipatory = md["anticipatory"]
 	return outErr
 }
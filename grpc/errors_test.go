@@ -9,7 +9,9 @@ import (
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 
 	"github.com/jmhodges/clock"
 
@@ -65,6 +67,30 @@ func TestErrorWrapping(t *testing.T) {
 	// Ensure our RetryAfter is still 500ms.
 	test.AssertEquals(t, bErr.RetryAfter, expectRetryAfter)
 
+	// A limit-specific RateLimitError should keep its limit name.
+	es.err = berrors.NewOrdersPerAccountError("NewOrdersPerAccount", expectRetryAfter, "yup")
+	_, err = client.Chill(context.Background(), &test_proto.Time{})
+	test.AssertDeepEquals(t, err, es.err)
+	bErr, ok = errors.AsType[*berrors.BoulderError](err)
+	test.Assert(t, ok, "asserting error as boulder error")
+	test.AssertEquals(t, bErr.RateLimitName(), "NewOrdersPerAccount")
+
+	// An anticipatory RateLimitError should remain anticipatory.
+	es.err = berrors.AnticipatoryRateLimitError(expectRetryAfter, "yup")
+	_, err = client.Chill(context.Background(), &test_proto.Time{})
+	test.AssertDeepEquals(t, err, es.err)
+	bErr, ok = errors.AsType[*berrors.BoulderError](err)
+	test.Assert(t, ok, "asserting error as boulder error")
+	test.Assert(t, bErr.IsAnticipatory(), "expected anticipatory error")
+
+	// An AccountDeactivated error should keep its type and carry a
+	// PermissionDenied status.
+	es.err = berrors.AccountDeactivatedError("account 1337 is deactivated")
+	_, err = client.Chill(context.Background(), &test_proto.Time{})
+	test.AssertDeepEquals(t, err, es.err)
+	test.AssertErrorIs(t, err, berrors.AccountDeactivated)
+	test.AssertEquals(t, status.Code(err), codes.PermissionDenied)
+
 	test.AssertNil(t, wrapError(context.Background(), nil), "Wrapping nil should still be nil")
 	test.AssertNil(t, unwrapError(nil, nil), "Unwrapping nil should still be nil")
 }
@@ -28,6 +28,9 @@ const (
 	TypeDNS = IdentifierType("dns")
 	// TypeIP is specified in RFC 8738
 	TypeIP = IdentifierType("ip")
+	// TypeUnsupported is not a registered identifier type. It is returned by
+	// ClassifyRawValue for values which can't be any supported type.
+	TypeUnsupported = IdentifierType("unsupported")
 )
 
 // IsValid tests whether the identifier type is known
@@ -122,6 +125,21 @@ func NewIP(ip netip.Addr) ACMEIdentifier {
 	}
 }
 
+// ClassifyRawValue returns the type of identifier that FromString would produce
+// for the given string: TypeIP if it parses as an IP address and TypeDNS
+// otherwise. The empty string is TypeUnsupported. No other validation is
+// performed.
+func ClassifyRawValue(s string) IdentifierType {
+	if s == "" {
+		return TypeUnsupported
+	}
+	_, err := netip.ParseAddr(s)
+	if err == nil {
+		return TypeIP
+	}
+	return TypeDNS
+}
+
 // FromString converts a string to an ACMEIdentifier.
 func FromString(identStr string) ACMEIdentifier {
 	ip, err := netip.ParseAddr(identStr)
@@ -207,6 +225,26 @@ func Normalize(idents ACMEIdentifiers) ACMEIdentifiers {
 	return slices.Compact(idents)
 }
 
+// IdentifierSetDiff compares the identifiers of an order to those of a CSR,
+// after normalizing both. It returns the identifiers which are in the order but
+// not the CSR, and those which are in the CSR but not the order. The inputs are
+// not modified.
+func IdentifierSetDiff(orderIdents, csrIdents ACMEIdentifiers) (missing, extra ACMEIdentifiers) {
+	orderSet := Normalize(slices.Clone(orderIdents))
+	csrSet := Normalize(slices.Clone(csrIdents))
+	for _, ident := range orderSet {
+		if !slices.Contains(csrSet, ident) {
+			missing = append(missing, ident)
+		}
+	}
+	for _, ident := range csrSet {
+		if !slices.Contains(orderSet, ident) {
+			extra = append(extra, ident)
+		}
+	}
+	return missing, extra
+}
+
 // ToValues returns a slice of DNS names and a slice of IP addresses in the
 // input. If an identifier type or IP address is invalid, it returns an error.
 func (idents ACMEIdentifiers) ToValues() ([]string, []net.IP, error) {
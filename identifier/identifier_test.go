@@ -43,6 +43,30 @@ func TestNewIP(t *testing.T) {
 	}
 }
 
+func TestClassifyRawValue(t *testing.T) {
+	cases := []struct {
+		value string
+		want  IdentifierType
+	}{
+		{"64.112.117.1", TypeIP},
+		{"2602:80a:6000:abad:cafe::1", TypeIP},
+		{"example.com", TypeDNS},
+		{"", TypeUnsupported},
+	}
+	for _, tc := range cases {
+		t.Run(tc.value, func(t *testing.T) {
+			t.Parallel()
+			got := ClassifyRawValue(tc.value)
+			if got != tc.want {
+				t.Errorf("ClassifyRawValue(%q) = %q, but want %q", tc.value, got, tc.want)
+			}
+			if got.IsValid() != (tc.want != TypeUnsupported) {
+				t.Errorf("ClassifyRawValue(%q).IsValid() = %t", tc.value, got.IsValid())
+			}
+		})
+	}
+}
+
 // TestFromX509 tests FromCert and FromCSR, which are fromX509's public
 // wrappers.
 func TestFromX509(t *testing.T) {
@@ -180,6 +204,54 @@ func TestNormalize(t *testing.T) {
 	}
 }
 
+func TestIdentifierSetDiff(t *testing.T) {
+	cases := []struct {
+		name        string
+		order       ACMEIdentifiers
+		csr         ACMEIdentifiers
+		wantMissing ACMEIdentifiers
+		wantExtra   ACMEIdentifiers
+	}{
+		{
+			name:  "exact match after normalization",
+			order: ACMEIdentifiers{NewDNS("alpha.example.com"), NewIP(netip.MustParseAddr("fe80::cafe"))},
+			csr: ACMEIdentifiers{
+				{Type: TypeIP, Value: "fe80::CAFE"},
+				{Type: TypeDNS, Value: "Alpha.example.com"},
+				{Type: TypeDNS, Value: "alpha.example.com"},
+			},
+		},
+		{
+			name:        "missing from CSR",
+			order:       ACMEIdentifiers{NewDNS("alpha.example.com"), NewDNS("beta.example.com")},
+			csr:         ACMEIdentifiers{NewDNS("alpha.example.com")},
+			wantMissing: ACMEIdentifiers{NewDNS("beta.example.com")},
+		},
+		{
+			name:      "extra in CSR",
+			order:     ACMEIdentifiers{NewDNS("alpha.example.com")},
+			csr:       ACMEIdentifiers{NewDNS("alpha.example.com"), NewIP(netip.MustParseAddr("10.0.0.1"))},
+			wantExtra: ACMEIdentifiers{NewIP(netip.MustParseAddr("10.0.0.1"))},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			csr := slices.Clone(tc.csr)
+			missing, extra := IdentifierSetDiff(tc.order, tc.csr)
+			if !slices.Equal(missing, tc.wantMissing) {
+				t.Errorf("Got missing %#v, but want %#v", missing, tc.wantMissing)
+			}
+			if !slices.Equal(extra, tc.wantExtra) {
+				t.Errorf("Got extra %#v, but want %#v", extra, tc.wantExtra)
+			}
+			if !slices.Equal(tc.csr, csr) {
+				t.Errorf("IdentifierSetDiff modified its input: got %#v, want %#v", tc.csr, csr)
+			}
+		})
+	}
+}
+
 func TestToValues(t *testing.T) {
 	cases := []struct {
 		name            string
@@ -15,10 +15,17 @@ import (
 // IssueCertificate.
 type MockCA struct {
 	PEM []byte
+
+	// IssueError, if set, is returned from IssueCertificate instead of the
+	// cert from PEM.
+	IssueError error
 }
 
 // IssueCertificate is a mock
 func (ca *MockCA) IssueCertificate(ctx context.Context, req *capb.IssueCertificateRequest, _ ...grpc.CallOption) (*capb.IssueCertificateResponse, error) {
+	if ca.IssueError != nil {
+		return nil, ca.IssueError
+	}
 	if ca.PEM == nil {
 		return nil, fmt.Errorf("MockCA's PEM field must be set before calling IssueCertificate")
 	}
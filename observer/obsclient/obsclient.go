@@ -5,6 +5,8 @@ import (
 	"crypto/tls"
 	"net"
 	"net/http"
+	"net/netip"
+	"time"
 )
 
 var secureClient = newClient(false)
@@ -19,6 +21,62 @@ func Client(insecure bool) *http.Client {
 	return secureClient
 }
 
+// ClientWithUserAgent returns a new *http.Client, with the appropriate TLS
+// configuration, which sets the given User-Agent header on every request that
+// doesn't already have one. Unlike Client, the returned client is not shared.
+func ClientWithUserAgent(insecure bool, ua string) *http.Client {
+	c := newClient(insecure)
+	c.Transport = &userAgentTransport{ua: ua, next: c.Transport}
+	return c
+}
+
+// ClientWithMinTLS returns a new *http.Client, with the appropriate TLS
+// configuration, which refuses to negotiate a TLS version lower than min (e.g.
+// tls.VersionTLS12). The insecure flag only disables certificate verification;
+// the minimum version is enforced either way. Unlike Client, the returned
+// client is not shared.
+func ClientWithMinTLS(insecure bool, min uint16) *http.Client {
+	c := newClient(insecure)
+	c.Transport.(*http.Transport).TLSClientConfig.MinVersion = min
+	return c
+}
+
+// ClientWithTimeout returns a new *http.Client, with the appropriate TLS
+// configuration, which bounds the total time of each request, including
+// connecting, the TLS handshake, and reading the response body, to timeout.
+// Unlike Client, the returned client is not shared.
+func ClientWithTimeout(insecure bool, timeout time.Duration) *http.Client {
+	c := newClient(insecure)
+	c.Timeout = timeout
+	return c
+}
+
+// ClientFromSource returns a new *http.Client, with the appropriate TLS
+// configuration, whose connections originate from the source address src. See
+// DialerFromSource. Unlike Client, the returned client is not shared.
+func ClientFromSource(insecure bool, src netip.Addr) *http.Client {
+	c := newClient(insecure)
+	c.Transport.(*http.Transport).DialContext = DialerFromSource(src).DialContext
+	return c
+}
+
+// userAgentTransport is an http.RoundTripper which sets a User-Agent header on
+// requests which lack one before passing them to the next RoundTripper.
+type userAgentTransport struct {
+	ua   string
+	next http.RoundTripper
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") != "" {
+		return t.next.RoundTrip(req)
+	}
+	// RoundTrippers must not modify the request, so set the header on a clone.
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.ua)
+	return t.next.RoundTrip(req)
+}
+
 func newClient(insecure bool) *http.Client {
 	// Use the default transport, because it comes with useful defaults that are
 	// not just the http.Transport zero-values.
@@ -36,3 +94,16 @@ func Dialer() *net.Dialer {
 		FallbackDelay: -1, // Disable IPv6-to-IPv4 fallback
 	}
 }
+
+// DialerFromSource returns a custom dialer, like Dialer, which binds outbound
+// connections to the source address src. This allows probes on multi-homed
+// hosts to exercise a particular egress path. If src is the zero or
+// unspecified address, it is equivalent to Dialer.
+func DialerFromSource(src netip.Addr) *net.Dialer {
+	d := Dialer()
+	if !src.IsValid() || src.IsUnspecified() {
+		return d
+	}
+	d.LocalAddr = &net.TCPAddr{IP: src.AsSlice(), Zone: src.Zone()}
+	return d
+}
@@ -0,0 +1,132 @@
+package obsclient
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestClientWithUserAgent(t *testing.T) {
+	t.Parallel()
+
+	var gotUA string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUA = r.Header.Get("User-Agent")
+	}))
+	defer srv.Close()
+
+	client := ClientWithUserAgent(false, "boulder-observer/1.0")
+
+	resp, err := client.Get(srv.URL)
+	test.AssertNotError(t, err, "GET failed")
+	resp.Body.Close()
+	test.AssertEquals(t, gotUA, "boulder-observer/1.0")
+
+	// An explicitly set User-Agent should be left alone.
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	test.AssertNotError(t, err, "creating request")
+	req.Header.Set("User-Agent", "custom")
+	resp, err = client.Do(req)
+	test.AssertNotError(t, err, "GET failed")
+	resp.Body.Close()
+	test.AssertEquals(t, gotUA, "custom")
+
+	// The shared clients should be unchanged.
+	test.Assert(t, Client(false).Transport != client.Transport, "shared client should not be modified")
+}
+
+func TestClientWithMinTLS(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	srv := httptest.NewUnstartedServer(handler)
+	srv.TLS = &tls.Config{MaxVersion: tls.VersionTLS11}
+	srv.StartTLS()
+	defer srv.Close()
+
+	// The insecure flag doesn't relax the minimum version.
+	client := ClientWithMinTLS(true, tls.VersionTLS12)
+	resp, err := client.Get(srv.URL)
+	if err == nil {
+		resp.Body.Close()
+	}
+	test.AssertError(t, err, "handshake with a TLS 1.1 server should have failed")
+
+	modern := httptest.NewTLSServer(handler)
+	defer modern.Close()
+
+	resp, err = client.Get(modern.URL)
+	test.AssertNotError(t, err, "GET failed")
+	resp.Body.Close()
+
+	// The shared clients should be unchanged.
+	test.AssertEquals(t, Client(true).Transport.(*http.Transport).TLSClientConfig.MinVersion, uint16(0))
+}
+
+func TestClientWithTimeout(t *testing.T) {
+	t.Parallel()
+
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	client := ClientWithTimeout(false, 50*time.Millisecond)
+	resp, err := client.Get(srv.URL)
+	if err == nil {
+		resp.Body.Close()
+	}
+	test.AssertError(t, err, "request to a slow server should have timed out")
+	test.AssertErrorIs(t, err, context.DeadlineExceeded)
+
+	// The shared clients should be unchanged.
+	test.AssertEquals(t, Client(false).Timeout, time.Duration(0))
+}
+
+func TestDialerFromSource(t *testing.T) {
+	t.Parallel()
+
+	for _, src := range []netip.Addr{{}, netip.IPv4Unspecified(), netip.IPv6Unspecified()} {
+		d := DialerFromSource(src)
+		test.AssertEquals(t, d.LocalAddr, nil)
+		test.AssertEquals(t, d.FallbackDelay, time.Duration(-1))
+	}
+
+	d := DialerFromSource(netip.MustParseAddr("127.0.0.1"))
+	test.AssertEquals(t, d.LocalAddr.String(), "127.0.0.1:0")
+	test.AssertEquals(t, d.FallbackDelay, time.Duration(-1))
+}
+
+func TestClientFromSource(t *testing.T) {
+	t.Parallel()
+
+	var gotRemote string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotRemote = r.RemoteAddr
+	}))
+	defer srv.Close()
+
+	client := ClientFromSource(false, netip.MustParseAddr("127.0.0.1"))
+	resp, err := client.Get(srv.URL)
+	test.AssertNotError(t, err, "GET failed")
+	resp.Body.Close()
+
+	remote, err := netip.ParseAddrPort(gotRemote)
+	test.AssertNotError(t, err, "parsing remote address")
+	test.AssertEquals(t, remote.Addr(), netip.MustParseAddr("127.0.0.1"))
+
+	// The shared clients should be unchanged.
+	test.Assert(t, Client(false).Transport != client.Transport, "shared client should not be modified")
+}
@@ -0,0 +1,49 @@
+package policy
+
+import (
+	"sync"
+
+	"github.com/golang/groupcache/lru"
+
+	"github.com/letsencrypt/boulder/identifier"
+)
+
+// decisionKey identifies a single identifier checked under a particular
+// identifier policy.
+type decisionKey struct {
+	policyHash string
+	ident      identifier.ACMEIdentifier
+}
+
+// decisionCache is a bounded cache of the individual identifiers which the
+// PA's policy permits. It is safe for concurrent access.
+//
+// Only permitted identifiers are cached. Rejections are always re-evaluated,
+// so that each one is logged with the blocklist entry responsible for it, and
+// so that a transient failure is never mistaken for a policy decision.
+type decisionCache struct {
+	// Note: This must be a regular mutex, not an RWMutex, because cache.Get()
+	// actually mutates the lru.Cache (by updating the last-used info).
+	sync.Mutex
+	cache *lru.Cache
+}
+
+func newDecisionCache(maxEntries int) *decisionCache {
+	return &decisionCache{cache: lru.New(maxEntries)}
+}
+
+// allowed returns true if ident has been cached as permitted under the policy
+// with the given hash.
+func (c *decisionCache) allowed(policyHash string, ident identifier.ACMEIdentifier) bool {
+	c.Lock()
+	defer c.Unlock()
+	_, ok := c.cache.Get(decisionKey{policyHash, ident})
+	return ok
+}
+
+// allow caches ident as permitted under the policy with the given hash.
+func (c *decisionCache) allow(policyHash string, ident identifier.ACMEIdentifier) {
+	c.Lock()
+	defer c.Unlock()
+	c.cache.Add(decisionKey{policyHash, ident}, struct{}{})
+}
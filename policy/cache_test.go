@@ -0,0 +1,26 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/letsencrypt/boulder/identifier"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestDecisionCache(t *testing.T) {
+	t.Parallel()
+
+	c := newDecisionCache(2)
+	allowed := identifier.NewDNS("allowed.example.com")
+	other := identifier.NewDNS("other.example.com")
+
+	c.allow("hash", allowed)
+	test.Assert(t, c.allowed("hash", allowed), "expected a cached decision")
+	test.Assert(t, !c.allowed("hash", other), "unexpected cached decision")
+	test.Assert(t, !c.allowed("otherhash", allowed), "decisions should not be shared across policies")
+
+	// The cache is bounded.
+	c.allow("hash", other)
+	c.allow("hash", identifier.NewDNS("another.example.com"))
+	test.Assert(t, !c.allowed("hash", allowed), "least recently used decision should have been evicted")
+}
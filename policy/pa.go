@@ -2,6 +2,7 @@ package policy
 
 import (
 	"crypto/sha256"
+	"crypto/x509"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -12,6 +13,7 @@ import (
 	"slices"
 	"strings"
 	"sync"
+	"unicode"
 
 	"golang.org/x/net/idna"
 	"golang.org/x/text/unicode/norm"
@@ -29,23 +31,213 @@ import (
 type AuthorityImpl struct {
 	log blog.Logger
 
-	domainBlocklist       map[string]bool
-	fqdnBlocklist         map[string]bool
-	wildcardFqdnBlocklist map[string]bool
+	domainBlocklist       map[string]blocklistSource
+	fqdnBlocklist         map[string]blocklistSource
+	wildcardFqdnBlocklist map[string]blocklistSource
+	regexBlocklist        []*regexp.Regexp
 	ipPrefixBlocklist     []netip.Prefix
+	allowedNames          map[string]bool
+	policyHash            string
 	blocklistMu           sync.RWMutex
 
+	// decisions, if non-nil, caches the individual identifiers which pass
+	// WillingToIssue's checks under the current policyHash.
+	decisions *decisionCache
+
 	enabledChallenges  map[core.AcmeChallenge]bool
 	enabledIdentifiers map[identifier.IdentifierType]bool
+
+	// reservedPrefixes supplements the IANA special-purpose address registries
+	// compiled into the iana package.
+	reservedPrefixes []netip.Prefix
+
+	// singleTypeProfiles is the set of certificate profile names for which
+	// every identifier in an order must be of the same type.
+	singleTypeProfiles map[string]bool
+
+	// assertLowercase causes WillingToIssue to reject DNS identifiers which
+	// violate its lowercase precondition.
+	assertLowercase bool
+
+	// allowedScripts, if non-nil, restricts the Unicode scripts which may
+	// appear in the U-label form of an IDN label.
+	allowedScripts map[string]*unicode.RangeTable
+
+	// allowLeadingUnderscore permits the leftmost label of a DNS identifier to
+	// begin with an underscore.
+	allowLeadingUnderscore bool
+
+	// maxDNSNameLength is the maximum length, in bytes, of a DNS identifier. It
+	// is never greater than maxDNSIdentifierLength.
+	maxDNSNameLength int
+
+	// maxLabels is the maximum number of labels in a DNS identifier.
+	maxLabels int
+
+	// maxSubErrors is the maximum number of sub-errors included in an error
+	// returned by WillingToIssue.
+	maxSubErrors int
+}
+
+// Option configures optional behavior of an AuthorityImpl. Options are applied
+// in order by New, which returns the first error encountered.
+type Option func(*AuthorityImpl) error
+
+// WithReservedPrefixes configures additional IP address prefixes which are
+// treated as reserved, augmenting the built-in IANA special-purpose address
+// registries. This allows operators to react to new IANA reservations without
+// waiting for a release.
+func WithReservedPrefixes(prefixes []string) Option {
+	return func(pa *AuthorityImpl) error {
+		for _, p := range prefixes {
+			prefix, err := netip.ParsePrefix(p)
+			if err != nil {
+				return fmt.Errorf("malformed reserved prefix, not a prefix: %q", p)
+			}
+			pa.reservedPrefixes = append(pa.reservedPrefixes, prefix.Masked())
+		}
+		return nil
+	}
+}
+
+// WithSingleIdentifierTypeProfiles configures certificate profiles for which
+// orders may not mix identifier types (e.g. DNS and IP).
+func WithSingleIdentifierTypeProfiles(profiles []string) Option {
+	return func(pa *AuthorityImpl) error {
+		if pa.singleTypeProfiles == nil {
+			pa.singleTypeProfiles = make(map[string]bool, len(profiles))
+		}
+		for _, p := range profiles {
+			pa.singleTypeProfiles[p] = true
+		}
+		return nil
+	}
+}
+
+// WithLowercaseAssertion enables or disables WillingToIssue's check that DNS
+// identifier values are lowercase. It is enabled by default.
+func WithLowercaseAssertion(enabled bool) Option {
+	return func(pa *AuthorityImpl) error {
+		pa.assertLowercase = enabled
+		return nil
+	}
+}
+
+// WithAllowedScripts restricts internationalized domain names to labels whose
+// characters, other than those common to all scripts (such as digits and
+// hyphens), belong to exactly one of the named Unicode scripts (e.g. "Latin",
+// "Cyrillic"). This guards against homograph domains which mix confusable
+// characters from different scripts. If scripts is empty, no restriction is
+// applied.
+func WithAllowedScripts(scripts []string) Option {
+	return func(pa *AuthorityImpl) error {
+		if len(scripts) == 0 {
+			return nil
+		}
+		pa.allowedScripts = make(map[string]*unicode.RangeTable, len(scripts))
+		for _, name := range scripts {
+			table, ok := unicode.Scripts[name]
+			if !ok {
+				return fmt.Errorf("unknown Unicode script %q", name)
+			}
+			pa.allowedScripts[name] = table
+		}
+		return nil
+	}
+}
+
+// WithLeadingUnderscoreLabels permits or forbids DNS identifiers whose leftmost
+// label begins with an underscore (e.g. "_dmarc.example.com"). They are
+// forbidden by default. Such names are not valid hostnames, so they must not be
+// permitted by a publicly-trusted CA; this exists for internal deployments.
+func WithLeadingUnderscoreLabels(allowed bool) Option {
+	return func(pa *AuthorityImpl) error {
+		pa.allowLeadingUnderscore = allowed
+		return nil
+	}
+}
+
+// WithMaxDNSNameLength configures a maximum length, in bytes, for DNS
+// identifiers which is stricter than the 253 bytes permitted by the DNS. If
+// maxLength is zero, the DNS limit is used.
+func WithMaxDNSNameLength(maxLength int) Option {
+	return func(pa *AuthorityImpl) error {
+		if maxLength < 0 || maxLength > maxDNSIdentifierLength {
+			return fmt.Errorf("max DNS name length must be between 1 and %d, got %d", maxDNSIdentifierLength, maxLength)
+		}
+		if maxLength > 0 {
+			pa.maxDNSNameLength = maxLength
+		}
+		return nil
+	}
+}
+
+// WithMaxLabels configures the maximum number of labels permitted in a DNS
+// identifier, in place of the default of 10. If limit is zero, the default is
+// used.
+func WithMaxLabels(limit int) Option {
+	return func(pa *AuthorityImpl) error {
+		if limit != 0 && (limit < 2 || limit > maxPossibleLabels) {
+			return fmt.Errorf("max labels must be between 2 and %d, got %d", maxPossibleLabels, limit)
+		}
+		if limit > 0 {
+			pa.maxLabels = limit
+		}
+		return nil
+	}
+}
+
+// WithMaxSubErrors configures the maximum number of sub-errors included in an
+// error returned by WillingToIssue, in place of the default of 100. If limit is
+// zero, the default is used.
+func WithMaxSubErrors(limit int) Option {
+	return func(pa *AuthorityImpl) error {
+		if limit < 0 {
+			return fmt.Errorf("max sub-errors must not be negative, got %d", limit)
+		}
+		if limit > 0 {
+			pa.maxSubErrors = limit
+		}
+		return nil
+	}
+}
+
+// WithDecisionCache configures WillingToIssue to cache up to maxEntries
+// individual identifiers which pass its checks. Rejected identifiers are not
+// cached. Entries are keyed by the hash of the identifier policy file, so
+// reloading a changed policy file invalidates them. Identifiers are not cached
+// unless the policy was loaded from a file. If maxEntries is zero, no cache is
+// used.
+func WithDecisionCache(maxEntries int) Option {
+	return func(pa *AuthorityImpl) error {
+		if maxEntries < 0 {
+			return fmt.Errorf("decision cache size must not be negative, got %d", maxEntries)
+		}
+		if maxEntries > 0 {
+			pa.decisions = newDecisionCache(maxEntries)
+		}
+		return nil
+	}
 }
 
 // New constructs a Policy Authority.
-func New(identifierTypes map[identifier.IdentifierType]bool, challengeTypes map[core.AcmeChallenge]bool, log blog.Logger) (*AuthorityImpl, error) {
-	return &AuthorityImpl{
+func New(identifierTypes map[identifier.IdentifierType]bool, challengeTypes map[core.AcmeChallenge]bool, log blog.Logger, opts ...Option) (*AuthorityImpl, error) {
+	pa := &AuthorityImpl{
 		log:                log,
 		enabledChallenges:  challengeTypes,
 		enabledIdentifiers: identifierTypes,
-	}, nil
+		assertLowercase:    true,
+		maxDNSNameLength:   maxDNSIdentifierLength,
+		maxLabels:          maxLabels,
+		maxSubErrors:       maxSubErrors,
+	}
+	for _, opt := range opts {
+		err := opt(pa)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pa, nil
 }
 
 // blockedIdentsPolicy is a struct holding lists of blocked identifiers.
@@ -73,48 +265,226 @@ type blockedIdentsPolicy struct {
 	// AdminBlockedPrefixes is a list of IP address prefixes. All IP addresses
 	// contained within the prefix are blocked.
 	AdminBlockedPrefixes []string `yaml:"AdminBlockedPrefixes"`
+
+	// RegexBlockedNames is an optional list of regular expressions. Issuance
+	// is blocked for any domain name which matches one of them, without regard
+	// to case. Patterns are unanchored, so most entries should begin with `^`
+	// and escape their dots (e.g. `^www\d+\.example\.com$`).
+	RegexBlockedNames []string `yaml:"RegexBlockedNames"`
+
+	// AllowedNames is an optional list of domain names. If it is non-empty,
+	// issuance is permitted only for DNS identifiers which are one of these
+	// names or a subdomain of one (e.g. AllowedNames containing `example.com`
+	// permits `www.example.com` but not `example.net`). The blocklists still
+	// apply to allowed names. IP identifiers are unaffected.
+	AllowedNames []string `yaml:"AllowedNames"`
+}
+
+// blocklistSource identifies which list in the blockedIdentsPolicy a blocklist
+// entry was loaded from, so that rejections can be attributed for auditing.
+type blocklistSource uint8
+
+const (
+	sourceHighRisk blocklistSource = iota + 1
+	sourceAdmin
+	sourceExact
+	sourceAdminPrefix
+	sourceRegex
+)
+
+func (s blocklistSource) String() string {
+	switch s {
+	case sourceHighRisk:
+		return "HighRiskBlockedNames"
+	case sourceAdmin:
+		return "AdminBlockedNames"
+	case sourceExact:
+		return "ExactBlockedNames"
+	case sourceAdminPrefix:
+		return "AdminBlockedPrefixes"
+	case sourceRegex:
+		return "RegexBlockedNames"
+	default:
+		return "unknown"
+	}
 }
 
 // LoadIdentPolicyFile will load the given policy file, returning an error if it
 // fails.
 func (pa *AuthorityImpl) LoadIdentPolicyFile(f string) error {
-	configBytes, err := os.ReadFile(f)
+	policy, hash, err := readIdentPolicyFile(f)
 	if err != nil {
 		return err
 	}
+	missing := missingRequiredLists(policy)
+	if len(missing) > 0 {
+		return fmt.Errorf("no entries in %s", missing[0])
+	}
+	pa.log.Infof("loading identifier policy, sha256: %s", hash)
+	return pa.loadIdentPolicy(policy, hash)
+}
+
+// readIdentPolicyFile reads and parses the given policy file, returning it
+// along with the hex-encoded SHA-256 hash of its contents.
+func readIdentPolicyFile(f string) (blockedIdentsPolicy, string, error) {
+	configBytes, err := os.ReadFile(f)
+	if err != nil {
+		return blockedIdentsPolicy{}, "", err
+	}
 	hash := sha256.Sum256(configBytes)
-	pa.log.Infof("loading identifier policy, sha256: %s", hex.EncodeToString(hash[:]))
 	var policy blockedIdentsPolicy
 	err = strictyaml.Unmarshal(configBytes, &policy)
 	if err != nil {
-		return err
+		return blockedIdentsPolicy{}, "", err
 	}
+	return policy, hex.EncodeToString(hash[:]), nil
+}
+
+// missingRequiredLists returns the names of the lists which must be non-empty
+// for the policy to be loaded, but are empty.
+func missingRequiredLists(policy blockedIdentsPolicy) []string {
+	var missing []string
 	if len(policy.HighRiskBlockedNames) == 0 {
-		return fmt.Errorf("no entries in HighRiskBlockedNames")
+		missing = append(missing, sourceHighRisk.String())
 	}
 	if len(policy.ExactBlockedNames) == 0 {
-		return fmt.Errorf("no entries in ExactBlockedNames")
+		missing = append(missing, sourceExact.String())
+	}
+	return missing
+}
+
+// PolicyReport summarizes an identifier policy file without loading it.
+type PolicyReport struct {
+	// SHA256 is the hex-encoded SHA-256 hash of the file's contents, as logged
+	// when the file is loaded.
+	SHA256 string
+	// Counts is the number of entries in each list, keyed by list name (e.g.
+	// "HighRiskBlockedNames").
+	Counts map[string]int
+	// MalformedEntries describes each entry, or missing required list, which
+	// would cause loading the file to fail.
+	MalformedEntries []string
+	// Warnings describes each entry which would not prevent the file from
+	// loading, but is likely a mistake (e.g. a duplicate).
+	Warnings []string
+}
+
+// DryRunIdentPolicyFile parses and validates the given policy file, returning
+// a report of its contents, without changing the policy currently in use. It
+// returns an error only if the file can't be read or parsed at all; problems
+// with individual entries, and required lists which are empty, are included in
+// the report.
+func (pa *AuthorityImpl) DryRunIdentPolicyFile(f string) (*PolicyReport, error) {
+	policy, hash, err := readIdentPolicyFile(f)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &PolicyReport{
+		SHA256: hash,
+		Counts: map[string]int{
+			sourceHighRisk.String():    len(policy.HighRiskBlockedNames),
+			sourceAdmin.String():       len(policy.AdminBlockedNames),
+			sourceExact.String():       len(policy.ExactBlockedNames),
+			sourceAdminPrefix.String(): len(policy.AdminBlockedPrefixes),
+			sourceRegex.String():       len(policy.RegexBlockedNames),
+			"AllowedNames":             len(policy.AllowedNames),
+		},
+	}
+
+	for _, list := range missingRequiredLists(policy) {
+		report.MalformedEntries = append(report.MalformedEntries, fmt.Sprintf("no entries in %s", list))
+	}
+
+	warnDuplicates := func(source blocklistSource, entries []string) {
+		seen := make(map[string]bool, len(entries))
+		for _, v := range entries {
+			if seen[v] {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("duplicate %s entry: %q", source, v))
+			}
+			seen[v] = true
+		}
+	}
+	warnDuplicates(sourceHighRisk, policy.HighRiskBlockedNames)
+	warnDuplicates(sourceAdmin, policy.AdminBlockedNames)
+	warnDuplicates(sourceExact, policy.ExactBlockedNames)
+	warnDuplicates(sourceAdminPrefix, policy.AdminBlockedPrefixes)
+	warnDuplicates(sourceRegex, policy.RegexBlockedNames)
+
+	for _, v := range policy.AdminBlockedNames {
+		if slices.Contains(policy.HighRiskBlockedNames, v) {
+			report.Warnings = append(report.Warnings, fmt.Sprintf(
+				"%s entry %q is also in %s", sourceAdmin, v, sourceHighRisk))
+		}
+	}
+
+	for _, v := range policy.ExactBlockedNames {
+		if !strings.Contains(v, ".") {
+			report.MalformedEntries = append(report.MalformedEntries, fmt.Sprintf(
+				"malformed %s entry, only one label: %q", sourceExact, v))
+		}
+	}
+	for _, p := range policy.AdminBlockedPrefixes {
+		_, err := netip.ParsePrefix(p)
+		if err != nil {
+			report.MalformedEntries = append(report.MalformedEntries, fmt.Sprintf(
+				"malformed %s entry, not a prefix: %q", sourceAdminPrefix, p))
+		}
 	}
-	return pa.processIdentPolicy(policy)
+	for _, v := range policy.RegexBlockedNames {
+		_, err := compileBlockedNameRegex(v)
+		if err != nil {
+			report.MalformedEntries = append(report.MalformedEntries, fmt.Sprintf(
+				"malformed %s entry, %s: %q", sourceRegex, err, v))
+		}
+	}
+
+	return report, nil
+}
+
+// compileBlockedNameRegex compiles a RegexBlockedNames entry. The resulting
+// regexp is case-insensitive.
+func compileBlockedNameRegex(v string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)" + v)
+}
+
+// loadedPolicyHash returns the hex-encoded SHA-256 hash of the identifier
+// policy file most recently loaded, or the empty string if the current
+// identifier policy was not loaded from a file.
+func (pa *AuthorityImpl) loadedPolicyHash() string {
+	pa.blocklistMu.RLock()
+	defer pa.blocklistMu.RUnlock()
+	return pa.policyHash
 }
 
 // processIdentPolicy handles loading a new blockedIdentsPolicy into the PA. All
 // of the policy.ExactBlockedNames will be added to the wildcardExactBlocklist
 // by processIdentPolicy to ensure that wildcards for exact blocked names
 // entries are forbidden.
+//
+// Each entry remembers the list it came from. A name which appears in both
+// HighRiskBlockedNames and AdminBlockedNames is attributed to the former.
 func (pa *AuthorityImpl) processIdentPolicy(policy blockedIdentsPolicy) error {
-	nameMap := make(map[string]bool)
+	return pa.loadIdentPolicy(policy, "")
+}
+
+// loadIdentPolicy is like processIdentPolicy, but additionally records the hash
+// of the policy file it was read from, if any.
+func (pa *AuthorityImpl) loadIdentPolicy(policy blockedIdentsPolicy, hash string) error {
+	nameMap := make(map[string]blocklistSource)
 	for _, v := range policy.HighRiskBlockedNames {
-		nameMap[v] = true
+		nameMap[v] = sourceHighRisk
 	}
 	for _, v := range policy.AdminBlockedNames {
-		nameMap[v] = true
+		if _, ok := nameMap[v]; !ok {
+			nameMap[v] = sourceAdmin
+		}
 	}
 
-	exactNameMap := make(map[string]bool)
-	wildcardNameMap := make(map[string]bool)
+	exactNameMap := make(map[string]blocklistSource)
+	wildcardNameMap := make(map[string]blocklistSource)
 	for _, v := range policy.ExactBlockedNames {
-		exactNameMap[v] = true
+		exactNameMap[v] = sourceExact
 		// Remove the leftmost label of the exact blocked names entry to make an exact
 		// wildcard block list entry that will prevent issuing a wildcard that would
 		// include the exact blocklist entry. e.g. if "highvalue.example.com" is on
@@ -131,7 +501,7 @@ func (pa *AuthorityImpl) processIdentPolicy(policy blockedIdentsPolicy) error {
 		}
 		// Add the second part, the domain minus the first label, to the
 		// wildcardNameMap to block issuance for `*.`+parts[1]
-		wildcardNameMap[parts[1]] = true
+		wildcardNameMap[parts[1]] = sourceExact
 	}
 
 	var prefixes []netip.Prefix
@@ -144,22 +514,49 @@ func (pa *AuthorityImpl) processIdentPolicy(policy blockedIdentsPolicy) error {
 		prefixes = append(prefixes, prefix)
 	}
 
+	var regexes []*regexp.Regexp
+	for _, v := range policy.RegexBlockedNames {
+		re, err := compileBlockedNameRegex(v)
+		if err != nil {
+			return fmt.Errorf(
+				"malformed RegexBlockedNames entry %q: %w", v, err)
+		}
+		regexes = append(regexes, re)
+	}
+
+	var allowedNames map[string]bool
+	if len(policy.AllowedNames) > 0 {
+		allowedNames = make(map[string]bool, len(policy.AllowedNames))
+		for _, v := range policy.AllowedNames {
+			allowedNames[v] = true
+		}
+	}
+
 	pa.blocklistMu.Lock()
 	pa.domainBlocklist = nameMap
 	pa.fqdnBlocklist = exactNameMap
 	pa.wildcardFqdnBlocklist = wildcardNameMap
+	pa.regexBlocklist = regexes
 	pa.ipPrefixBlocklist = prefixes
+	pa.allowedNames = allowedNames
+	pa.policyHash = hash
 	pa.blocklistMu.Unlock()
 	return nil
 }
 
-// The values of maxDNSIdentifierLength, maxLabelLength and maxLabels are hard coded
-// into the error messages errNameTooLong, errLabelTooLong and errTooManyLabels.
-// If their values change, the related error messages should be updated.
+// The values of maxDNSIdentifierLength and maxLabelLength are hard coded into
+// the error messages errNameTooLong and errLabelTooLong. If their values
+// change, the related error messages should be updated.
 
 const (
+	// maxLabels is the default maximum number of labels in a DNS identifier.
+	// It can be overridden with WithMaxLabels.
 	maxLabels = 10
 
+	// maxPossibleLabels is the greatest number of labels which can fit within
+	// maxDNSIdentifierLength: single-byte labels separated by dots.
+	maxPossibleLabels = (maxDNSIdentifierLength + 1) / 2
+
 	// RFC 1034 says DNS labels have a max of 63 octets, and names have a max of 255
 	// octets: https://tools.ietf.org/html/rfc1035#page-10. Since two of those octets
 	// are taken up by the leading length byte and the trailing root period the actual
@@ -180,7 +577,6 @@ func isDNSCharacter(ch byte) bool {
 // In these error messages:
 //   253 is the value of maxDNSIdentifierLength
 //   63 is the value of maxLabelLength
-//   10 is the value of maxLabels
 // If these values change, the related error messages should be updated.
 
 var (
@@ -188,10 +584,12 @@ var (
 	errICANNTLD             = berrors.MalformedError("Domain name is an ICANN TLD")
 	errPolicyForbidden      = berrors.RejectedIdentifierError("The ACME server refuses to issue a certificate for this domain name, because it is forbidden by policy")
 	errInvalidDNSCharacter  = berrors.MalformedError("Domain name contains an invalid character")
+	errLeadingUnderscore    = berrors.MalformedError("Domain name has a label beginning with an underscore")
 	errNameTooLong          = berrors.MalformedError("Domain name is longer than 253 bytes")
 	errIPAddressInDNS       = berrors.MalformedError("Identifier type is DNS but value is an IP address")
 	errIPInvalid            = berrors.MalformedError("IP address is invalid")
-	errTooManyLabels        = berrors.MalformedError("Domain name has more than 10 labels (parts)")
+	errIPReserved           = berrors.MalformedError("IP address is in a reserved address block")
+	errTooManyLabels        = tooManyLabelsError(maxLabels)
 	errEmptyIdentifier      = berrors.MalformedError("Identifier value (name) is empty")
 	errNameEndsInDot        = berrors.MalformedError("Domain name ends in a dot")
 	errTooFewLabels         = berrors.MalformedError("Domain name needs at least one dot")
@@ -199,6 +597,7 @@ var (
 	errLabelTooLong         = berrors.MalformedError("Domain has a label (component between dots) longer than 63 bytes")
 	errMalformedIDN         = berrors.MalformedError("Domain name contains malformed punycode")
 	errInvalidRLDH          = berrors.RejectedIdentifierError("Domain name contains an invalid label in a reserved format (R-LDH: '??--')")
+	errDisallowedScript     = berrors.RejectedIdentifierError("Domain name contains a label which mixes scripts or uses a disallowed script")
 	errTooManyWildcards     = berrors.MalformedError("Domain name has more than one wildcard")
 	errMalformedWildcard    = berrors.MalformedError("Domain name contains an invalid wildcard. A wildcard is only permitted before the first dot in a domain name")
 	errICANNTLDWildcard     = berrors.MalformedError("Domain name is a wildcard for an ICANN TLD")
@@ -206,20 +605,69 @@ var (
 	errUnsupportedIdent     = berrors.MalformedError("Invalid identifier type")
 )
 
+// tooManyLabelsError returns the error for a domain name with more than
+// labelLimit labels.
+func tooManyLabelsError(labelLimit int) error {
+	return berrors.MalformedError("Domain name has more than %d labels (parts)", labelLimit)
+}
+
+// nameTooLongError returns the error for a domain name longer than maxLength
+// bytes.
+func nameTooLongError(maxLength int) error {
+	if maxLength >= maxDNSIdentifierLength {
+		return errNameTooLong
+	}
+	return berrors.MalformedError("Domain name is longer than the configured maximum of %d bytes", maxLength)
+}
+
+// domainConstraints are the limits on DNS identifiers which a PolicyAuthority
+// can configure.
+type domainConstraints struct {
+	// maxLength is the maximum length of a domain name, in bytes. It is never
+	// greater than maxDNSIdentifierLength.
+	maxLength int
+
+	// maxLabels is the maximum number of labels in a domain name.
+	maxLabels int
+
+	// allowLeadingUnderscore permits the leftmost label of a non-wildcard
+	// domain name to begin with an underscore. The remainder of that label must
+	// be a valid LDH label.
+	allowLeadingUnderscore bool
+}
+
+// defaultDomainConstraints are the constraints enforced by ValidDomain.
+var defaultDomainConstraints = domainConstraints{
+	maxLength: maxDNSIdentifierLength,
+	maxLabels: maxLabels,
+}
+
+// lenientDomainConstraints are the constraints enforced by
+// WellFormedIdentifiersLenient: the loosest that any PolicyAuthority can be
+// configured with.
+var lenientDomainConstraints = domainConstraints{
+	maxLength:              maxDNSIdentifierLength,
+	maxLabels:              maxPossibleLabels,
+	allowLeadingUnderscore: true,
+}
+
 // validNonWildcardDomain checks that a domain isn't:
 //   - empty
 //   - prefixed with the wildcard label `*.`
+//   - made of labels beginning with an underscore, except for the leftmost
+//     label if c.allowLeadingUnderscore is set
 //   - made of invalid DNS characters
-//   - longer than the maxDNSIdentifierLength
+//   - longer than c.maxLength
 //   - an IPv4 or IPv6 address
 //   - suffixed with just "."
-//   - made of too many DNS labels
+//   - made of more than c.maxLabels DNS labels
 //   - made of any invalid DNS labels
 //   - suffixed with something other than an IANA registered TLD
-//   - exactly equal to an IANA registered TLD
+//   - exactly equal to an IANA registered TLD, ignoring any leading
+//     underscore label
 //
 // It does NOT ensure that the domain is absent from any PA blocked lists.
-func validNonWildcardDomain(domain string) error {
+func validNonWildcardDomain(domain string, c domainConstraints) error {
 	if domain == "" {
 		return errEmptyIdentifier
 	}
@@ -228,14 +676,21 @@ func validNonWildcardDomain(domain string) error {
 		return errWildcardNotSupported
 	}
 
-	for _, ch := range []byte(domain) {
+	underscored := c.allowLeadingUnderscore && strings.HasPrefix(domain, "_")
+	for i, ch := range []byte(domain) {
+		if ch == '_' && (i == 0 || domain[i-1] == '.') {
+			if i == 0 && underscored {
+				continue
+			}
+			return errLeadingUnderscore
+		}
 		if !isDNSCharacter(ch) {
 			return errInvalidDNSCharacter
 		}
 	}
 
-	if len(domain) > maxDNSIdentifierLength {
-		return errNameTooLong
+	if len(domain) > c.maxLength {
+		return nameTooLongError(c.maxLength)
 	}
 
 	_, err := netip.ParseAddr(domain)
@@ -248,13 +703,22 @@ func validNonWildcardDomain(domain string) error {
 	}
 
 	labels := strings.Split(domain, ".")
-	if len(labels) > maxLabels {
-		return errTooManyLabels
+	if len(labels) > c.maxLabels {
+		return tooManyLabelsError(c.maxLabels)
 	}
 	if len(labels) < 2 {
 		return errTooFewLabels
 	}
-	for _, label := range labels {
+	for i, label := range labels {
+		if len(label) > maxLabelLength {
+			return errLabelTooLong
+		}
+		// The remainder of a leading underscore label is held to the same rules
+		// as any other label.
+		if i == 0 && underscored {
+			label = label[1:]
+		}
+
 		// Check that this is a valid LDH Label: "A string consisting of ASCII
 		// letters, digits, and the hyphen with the further restriction that the
 		// hyphen cannot appear at the beginning or end of the string. Like all DNS
@@ -262,9 +726,6 @@ func validNonWildcardDomain(domain string) error {
 		if len(label) < 1 {
 			return errLabelTooShort
 		}
-		if len(label) > maxLabelLength {
-			return errLabelTooLong
-		}
 		if !dnsLabelCharacterRegexp.MatchString(label) {
 			return errInvalidDNSCharacter
 		}
@@ -296,12 +757,17 @@ func validNonWildcardDomain(domain string) error {
 		}
 	}
 
-	// Names must end in an ICANN TLD, but they must not be equal to an ICANN TLD.
-	icannTLD, err := iana.ExtractSuffix(domain)
+	// Names must end in an ICANN TLD, but they must not be equal to an ICANN
+	// TLD. An underscore label must not sit directly beneath one either.
+	name := domain
+	if underscored {
+		name = strings.Join(labels[1:], ".")
+	}
+	icannTLD, err := iana.ExtractSuffix(name)
 	if err != nil {
 		return errNonPublic
 	}
-	if icannTLD == domain {
+	if icannTLD == name {
 		return errICANNTLD
 	}
 
@@ -312,8 +778,14 @@ func validNonWildcardDomain(domain string) error {
 // invalid wildcard characters. It does NOT ensure that the domain is absent
 // from any PA blocked lists.
 func ValidDomain(domain string) error {
+	return validDomain(domain, defaultDomainConstraints)
+}
+
+// validDomain is like ValidDomain, but enforces the given constraints. A
+// leading underscore is never permitted in the base domain of a wildcard.
+func validDomain(domain string, c domainConstraints) error {
 	if strings.Count(domain, "*") <= 0 {
-		return validNonWildcardDomain(domain)
+		return validNonWildcardDomain(domain, c)
 	}
 
 	// Names containing more than one wildcard are invalid.
@@ -327,6 +799,12 @@ func ValidDomain(domain string) error {
 		return errMalformedWildcard
 	}
 
+	// A configured length limit applies to the name including its wildcard
+	// label.
+	if c.maxLength < maxDNSIdentifierLength && len(domain) > c.maxLength {
+		return nameTooLongError(c.maxLength)
+	}
+
 	// The base domain is the wildcard request with the `*.` prefix removed
 	baseDomain := strings.TrimPrefix(domain, "*.")
 
@@ -340,7 +818,18 @@ func ValidDomain(domain string) error {
 	if baseDomain == icannTLD {
 		return errICANNTLDWildcard
 	}
-	return validNonWildcardDomain(baseDomain)
+	c.allowLeadingUnderscore = false
+	return validNonWildcardDomain(baseDomain, c)
+}
+
+// validDomain is like ValidDomain, but enforces the constraints configured with
+// WithMaxDNSNameLength, WithMaxLabels, and WithLeadingUnderscoreLabels.
+func (pa *AuthorityImpl) validDomain(domain string) error {
+	return validDomain(domain, domainConstraints{
+		maxLength:              pa.maxDNSNameLength,
+		maxLabels:              pa.maxLabels,
+		allowLeadingUnderscore: pa.allowLeadingUnderscore,
+	})
 }
 
 // ValidIP checks that an IP address:
@@ -368,6 +857,57 @@ func ValidIP(ip string) error {
 	return iana.IsReservedAddr(parsedIP)
 }
 
+// ValidIP performs the same checks as the package-level ValidIP, and
+// additionally checks that the IP address isn't within any of the supplemental
+// reserved prefixes configured with WithReservedPrefixes.
+func (pa *AuthorityImpl) ValidIP(ip string) error {
+	err := ValidIP(ip)
+	if err != nil {
+		return err
+	}
+	parsedIP := netip.MustParseAddr(ip)
+	for _, prefix := range pa.reservedPrefixes {
+		if prefix.Contains(parsedIP) {
+			return errIPReserved
+		}
+	}
+	return nil
+}
+
+// checkScripts returns errDisallowedScript if any P-label of the provided
+// well-formed domain decodes to characters from more than one Unicode script,
+// or from a script not configured with WithAllowedScripts. Characters in the
+// Common and Inherited scripts are permitted in any label.
+func (pa *AuthorityImpl) checkScripts(domain string) error {
+	for label := range strings.SplitSeq(domain, ".") {
+		if !strings.HasPrefix(label, "xn--") {
+			continue
+		}
+		ulabel, err := idna.ToUnicode(label)
+		if err != nil {
+			return errMalformedIDN
+		}
+		var labelScript string
+		for _, r := range ulabel {
+			if unicode.In(r, unicode.Common, unicode.Inherited) {
+				continue
+			}
+			script := ""
+			for name, table := range pa.allowedScripts {
+				if unicode.Is(table, r) {
+					script = name
+					break
+				}
+			}
+			if script == "" || (labelScript != "" && script != labelScript) {
+				return errDisallowedScript
+			}
+			labelScript = script
+		}
+	}
+	return nil
+}
+
 // forbiddenMailDomains is a map of domain names we do not allow after the
 // @ symbol in contact mailto addresses. These are frequently used when
 // copy-pasting example configurations and would not result in expiration
@@ -390,7 +930,7 @@ func ValidEmail(address string) error {
 	}
 	splitEmail := strings.Split(email.Address, "@")
 	domain := strings.ToLower(splitEmail[len(splitEmail)-1])
-	err = validNonWildcardDomain(domain)
+	err = validNonWildcardDomain(domain, defaultDomainConstraints)
 	if err != nil {
 		return berrors.InvalidEmailError("contact email has invalid domain: %s", err)
 	}
@@ -402,6 +942,55 @@ func ValidEmail(address string) error {
 	return nil
 }
 
+// ValidEmails validates a comma-separated list of email addresses, each with
+// ValidEmail. Surrounding whitespace and empty entries are ignored, but the
+// list must contain at least one address. If any address is invalid, the
+// returned error has a sub-error for each invalid address, even if there is
+// only one. Like ValidEmail's errors, these don't include the address itself;
+// instead they identify it by its 1-based position in the list.
+func ValidEmails(addresses string) error {
+	var emails []string
+	for _, address := range strings.Split(addresses, ",") {
+		address = strings.TrimSpace(address)
+		if address != "" {
+			emails = append(emails, address)
+		}
+	}
+	if len(emails) == 0 {
+		return berrors.InvalidEmailError("no contact email addresses provided")
+	}
+
+	var subErrors []berrors.SubBoulderError
+	for i, email := range emails {
+		err := ValidEmail(email)
+		if err == nil {
+			continue
+		}
+		bErr, ok := errors.AsType[*berrors.BoulderError](err)
+		if !ok {
+			return err
+		}
+		subErrors = append(subErrors, berrors.SubBoulderError{
+			BoulderError: &berrors.BoulderError{
+				Type:   bErr.Type,
+				Detail: fmt.Sprintf("contact email %d: %s", i+1, bErr.Detail),
+			},
+		})
+	}
+	if len(subErrors) == 0 {
+		return nil
+	}
+	detail := subErrors[0].Detail
+	if len(subErrors) > 1 {
+		detail = fmt.Sprintf("%s (and %d more problems. Refer to sub-problems for more information.)",
+			detail, len(subErrors)-1)
+	}
+	return (&berrors.BoulderError{
+		Type:   berrors.InvalidEmail,
+		Detail: detail,
+	}).WithSubErrors(subErrors)
+}
+
 // subError returns an appropriately typed error based on the input error
 func subError(ident identifier.ACMEIdentifier, err error) berrors.SubBoulderError {
 	bErr, ok := errors.AsType[*berrors.BoulderError](err)
@@ -430,42 +1019,191 @@ func subError(ident identifier.ACMEIdentifier, err error) berrors.SubBoulderErro
 // If multiple identifiers are invalid, the error will contain suberrors
 // specific to each identifier.
 //
-// Precondition: all input identifier values must be in lowercase.
+// Precondition: all input identifier values must be in lowercase. Unless
+// disabled with WithLowercaseAssertion, a DNS identifier which violates this
+// precondition results in a Malformed error, since it indicates a caller bug.
 func (pa *AuthorityImpl) WillingToIssue(idents identifier.ACMEIdentifiers) error {
-	err := WellFormedIdentifiers(idents)
+	if pa.assertLowercase {
+		for _, ident := range idents {
+			if ident.Type == identifier.TypeDNS && strings.ToLower(ident.Value) != ident.Value {
+				return berrors.MalformedError("DNS identifier %q must be lowercase", ident.Value)
+			}
+		}
+	}
+
+	err := wellFormedIdentifiers(idents, pa.validDomain, pa.maxSubErrors)
 	if err != nil {
 		return err
 	}
 
 	var subErrors []berrors.SubBoulderError
 	for _, ident := range idents {
-		if !pa.IdentifierTypeEnabled(ident.Type) {
-			subErrors = append(subErrors, subError(ident, berrors.RejectedIdentifierError("The ACME server has disabled this identifier type")))
+		err := pa.cachedCheckIdentifier(ident)
+		if err != nil {
+			subErrors = append(subErrors, subError(ident, err))
+		}
+	}
+	return combineSubErrors(subErrors, pa.maxSubErrors)
+}
+
+// CheckIdentifiers performs the same checks as WillingToIssue, but returns the
+// result for each identifier individually rather than combining them: a map
+// from each identifier's value to the error which would prevent issuance for
+// it, or nil if issuance is allowed.
+func (pa *AuthorityImpl) CheckIdentifiers(idents identifier.ACMEIdentifiers) map[string]error {
+	results := make(map[string]error, len(idents))
+	for _, ident := range idents {
+		results[ident.Value] = pa.willingToIssueOne(ident)
+	}
+	return results
+}
+
+// PartitionIssuable performs the same checks as WillingToIssue, but rather than
+// rejecting all of the identifiers if any of them are unacceptable, it returns
+// the acceptable identifiers and a sub-error for each unacceptable one. The
+// caller decides whether to proceed with only the acceptable identifiers.
+func (pa *AuthorityImpl) PartitionIssuable(idents identifier.ACMEIdentifiers) (allowed identifier.ACMEIdentifiers, rejected []berrors.SubBoulderError) {
+	for _, ident := range idents {
+		err := pa.willingToIssueOne(ident)
+		if err != nil {
+			rejected = append(rejected, subError(ident, err))
 			continue
 		}
+		allowed = append(allowed, ident)
+	}
+	return allowed, rejected
+}
+
+// willingToIssueOne performs WillingToIssue's checks for a single identifier.
+func (pa *AuthorityImpl) willingToIssueOne(ident identifier.ACMEIdentifier) error {
+	if pa.assertLowercase && ident.Type == identifier.TypeDNS && strings.ToLower(ident.Value) != ident.Value {
+		return berrors.MalformedError("DNS identifier %q must be lowercase", ident.Value)
+	}
+	err := wellFormedIdentifier(ident, pa.validDomain)
+	if err != nil {
+		return err
+	}
+	return pa.cachedCheckIdentifier(ident)
+}
 
-		// Wildcard DNS identifiers are checked against an additional blocklist.
-		if ident.Type == identifier.TypeDNS && strings.Count(ident.Value, "*") > 0 {
-			// The base domain is the wildcard request with the `*.` prefix removed
-			baseDomain := strings.TrimPrefix(ident.Value, "*.")
+// AuditCertificate checks whether the CA would currently be willing to issue
+// for every identifier in the provided certificate. It returns the error from
+// WillingToIssue, with a sub-error for each identifier which is now forbidden
+// (e.g. because it has since been added to a blocklist). This is intended for
+// deciding which existing certificates to revoke after a policy change.
+func (pa *AuthorityImpl) AuditCertificate(cert *x509.Certificate) error {
+	idents := identifier.Normalize(identifier.FromCert(cert))
+	if len(idents) == 0 {
+		return berrors.MalformedError("certificate %x contains no identifiers", cert.SerialNumber)
+	}
+	return pa.WillingToIssue(idents)
+}
 
-			// The base domain can't be in the wildcard exact blocklist
-			err = pa.checkWildcardBlocklist(baseDomain)
-			if err != nil {
-				subErrors = append(subErrors, subError(ident, err))
-				continue
-			}
+// cachedCheckIdentifier returns the result of checkIdentifier, consulting and
+// populating the decision cache if one is configured and an identifier policy
+// has been loaded from a file.
+func (pa *AuthorityImpl) cachedCheckIdentifier(ident identifier.ACMEIdentifier) error {
+	if pa.decisions == nil {
+		return pa.checkIdentifier(ident)
+	}
+
+	hash := pa.loadedPolicyHash()
+	if hash == "" {
+		return pa.checkIdentifier(ident)
+	}
+
+	if pa.decisions.allowed(hash, ident) {
+		return nil
+	}
+	err := pa.checkIdentifier(ident)
+
+	// Only cache the decision if the policy wasn't reloaded while it was being
+	// made, since it may reflect the new policy rather than the old one.
+	if err == nil && pa.loadedPolicyHash() == hash {
+		pa.decisions.allow(hash, ident)
+	}
+	return err
+}
+
+// checkIdentifier checks whether the CA is willing to issue for the provided
+// well-formed identifier, returning an error if not.
+func (pa *AuthorityImpl) checkIdentifier(ident identifier.ACMEIdentifier) error {
+	_, err := pa.classifyIdentifier(ident)
+	return err
+}
+
+// ClassifyRejection performs the same checks as WillingToIssue for a single
+// identifier, without using the decision cache, and returns a stable reason
+// string suitable for use as a metric label along with the underlying error.
+// The reason is one of:
+//   - "ok": issuance is permitted, and the error is nil
+//   - "malformed": the identifier is not well-formed
+//   - "disabled-type": the identifier's type is disabled
+//   - "wildcard-blocklisted": the base domain of a wildcard is blocked
+//   - "blocklisted": the identifier is otherwise forbidden by policy, e.g. by
+//     the blocklists, a reserved IP prefix, or the allowlist
+func (pa *AuthorityImpl) ClassifyRejection(ident identifier.ACMEIdentifier) (string, error) {
+	if pa.assertLowercase && ident.Type == identifier.TypeDNS && strings.ToLower(ident.Value) != ident.Value {
+		return "malformed", berrors.MalformedError("DNS identifier %q must be lowercase", ident.Value)
+	}
+	err := wellFormedIdentifier(ident, pa.validDomain)
+	if err != nil {
+		return "malformed", err
+	}
+	return pa.classifyIdentifier(ident)
+}
+
+// classifyIdentifier implements checkIdentifier, additionally returning the
+// reason for any rejection as described by ClassifyRejection.
+func (pa *AuthorityImpl) classifyIdentifier(ident identifier.ACMEIdentifier) (string, error) {
+	if !pa.IdentifierTypeEnabled(ident.Type) {
+		return "disabled-type", berrors.RejectedIdentifierError("The ACME server has disabled this identifier type")
+	}
+
+	// IP identifiers are additionally checked against any supplemental
+	// reserved prefixes.
+	if ident.Type == identifier.TypeIP {
+		err := pa.ValidIP(ident.Value)
+		if err != nil {
+			return "blocklisted", err
 		}
+	}
 
-		// For all identifier types, check whether the identifier value is
-		// covered by the regular blocklists.
-		err := pa.checkBlocklists(ident)
+	// Wildcard DNS identifiers are checked against an additional blocklist.
+	if ident.Type == identifier.TypeDNS && strings.Count(ident.Value, "*") > 0 {
+		// The base domain is the wildcard request with the `*.` prefix removed
+		baseDomain := strings.TrimPrefix(ident.Value, "*.")
+
+		// The base domain can't be in the wildcard exact blocklist
+		err := pa.checkWildcardBlocklist(baseDomain)
 		if err != nil {
-			subErrors = append(subErrors, subError(ident, err))
-			continue
+			return "wildcard-blocklisted", err
 		}
 	}
-	return combineSubErrors(subErrors)
+
+	// Internationalized DNS identifiers are checked against any allowed
+	// scripts.
+	if ident.Type == identifier.TypeDNS && pa.allowedScripts != nil {
+		err := pa.checkScripts(ident.Value)
+		if err != nil {
+			return "blocklisted", err
+		}
+	}
+
+	// For all identifier types, check whether the identifier value is
+	// covered by the regular blocklists.
+	err := pa.checkBlocklists(ident)
+	if err != nil {
+		return "blocklisted", err
+	}
+
+	// DNS identifiers must additionally be covered by the allowlist, if there
+	// is one.
+	err = pa.checkAllowlist(ident)
+	if err != nil {
+		return "blocklisted", err
+	}
+	return "ok", nil
 }
 
 // WellFormedIdentifiers returns an error if any of the provided identifiers do
@@ -498,27 +1236,105 @@ func (pa *AuthorityImpl) WillingToIssue(idents identifier.ACMEIdentifiers) error
 // If multiple identifiers are invalid, the error will contain suberrors
 // specific to each identifier.
 func WellFormedIdentifiers(idents identifier.ACMEIdentifiers) error {
+	return wellFormedIdentifiers(idents, ValidDomain, maxSubErrors)
+}
+
+// WellFormedIdentifiersLenient is like WellFormedIdentifiers, but does not
+// apply the DNS identifier limits which a PolicyAuthority can relax: it
+// permits as many labels as fit within 253 bytes and a leading underscore in
+// the leftmost label. It is intended for callers, such as the WFE, which
+// reject malformed identifiers early and leave the configured limits to the
+// PolicyAuthority's WillingToIssue.
+func WellFormedIdentifiersLenient(idents identifier.ACMEIdentifiers) error {
+	return wellFormedIdentifiers(idents, func(domain string) error {
+		return validDomain(domain, lenientDomainConstraints)
+	}, maxSubErrors)
+}
+
+// wellFormedIdentifiers implements WellFormedIdentifiers, using validDomain to
+// check DNS identifiers and including at most limit sub-errors.
+func wellFormedIdentifiers(idents identifier.ACMEIdentifiers, validDomain func(string) error, limit int) error {
+	var subErrors []berrors.SubBoulderError
+	for _, ident := range idents {
+		err := wellFormedIdentifier(ident, validDomain)
+		if err != nil {
+			subErrors = append(subErrors, subError(ident, err))
+		}
+	}
+	return combineSubErrors(subErrors, limit)
+}
+
+// wellFormedIdentifier returns an error if the provided identifier is not of a
+// supported type or is malformed, using validDomain to check DNS identifiers.
+func wellFormedIdentifier(ident identifier.ACMEIdentifier, validDomain func(string) error) error {
+	switch ident.Type {
+	case identifier.TypeDNS:
+		return validDomain(ident.Value)
+	case identifier.TypeIP:
+		return ValidIP(ident.Value)
+	default:
+		return errUnsupportedIdent
+	}
+}
+
+// AllIdentifiersOfType returns true if every identifier in idents is of type
+// t. It returns true for an empty slice.
+func AllIdentifiersOfType(idents identifier.ACMEIdentifiers, t identifier.IdentifierType) bool {
+	for _, ident := range idents {
+		if ident.Type != t {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckProfileIdentifierTypes checks the provided identifiers against the
+// named certificate profile. Each identifier whose type is not among the
+// profile's permitted types results in a RejectedIdentifier sub-error, combined
+// in the same way as by WillingToIssue. Otherwise, it returns a Malformed error
+// if the profile requires all identifiers in an order to share a type and the
+// provided identifiers do not.
+func (pa *AuthorityImpl) CheckProfileIdentifierTypes(profile string, permitted []identifier.IdentifierType, idents identifier.ACMEIdentifiers) error {
 	var subErrors []berrors.SubBoulderError
 	for _, ident := range idents {
+		if slices.Contains(permitted, ident.Type) {
+			continue
+		}
+		identType := "unknown"
 		switch ident.Type {
-		case identifier.TypeDNS:
-			err := ValidDomain(ident.Value)
-			if err != nil {
-				subErrors = append(subErrors, subError(ident, err))
-			}
 		case identifier.TypeIP:
-			err := ValidIP(ident.Value)
-			if err != nil {
-				subErrors = append(subErrors, subError(ident, err))
-			}
-		default:
-			subErrors = append(subErrors, subError(ident, errUnsupportedIdent))
+			identType = "IP address"
+		case identifier.TypeDNS:
+			identType = "DNS"
 		}
+		subErrors = append(subErrors, subError(ident, berrors.RejectedIdentifierError(
+			"Profile %q does not permit %s identifiers. "+
+				"See available profiles at https://letsencrypt.org/docs/profiles/.", profile, identType)))
 	}
-	return combineSubErrors(subErrors)
+	err := combineSubErrors(subErrors, pa.maxSubErrors)
+	if err != nil {
+		return err
+	}
+
+	if !pa.singleTypeProfiles[profile] || len(idents) == 0 {
+		return nil
+	}
+	if !AllIdentifiersOfType(idents, idents[0].Type) {
+		return berrors.MalformedError("Profile %q does not permit orders with more than one identifier type", profile)
+	}
+	return nil
 }
 
-func combineSubErrors(subErrors []berrors.SubBoulderError) error {
+// maxSubErrors is the default maximum number of sub-errors included in an error
+// returned by WellFormedIdentifiers or WillingToIssue. Any further sub-errors
+// are counted in the top-level detail but otherwise omitted, to bound the size
+// of the resulting problem document.
+const maxSubErrors = 100
+
+// combineSubErrors returns nil if there are no sub-errors, the only sub-error
+// as a top-level error if there is one, and otherwise a RejectedIdentifier
+// error carrying at most limit of the sub-errors.
+func combineSubErrors(subErrors []berrors.SubBoulderError, limit int) error {
 	if len(subErrors) > 0 {
 		// If there was only one error, then use it as the top level error that is
 		// returned.
@@ -531,14 +1347,18 @@ func combineSubErrors(subErrors []berrors.SubBoulderError) error {
 		}
 
 		detail := fmt.Sprintf(
-			"Cannot issue for %q: %s (and %d more problems. Refer to sub-problems for more information.)",
+			"Cannot issue for %q: %s (and %d more problems. Refer to sub-problems for more information",
 			subErrors[0].Identifier.Value,
 			subErrors[0].BoulderError.Detail,
 			len(subErrors)-1,
 		)
+		if len(subErrors) > limit {
+			detail += fmt.Sprintf("; only the first %d are included", limit)
+			subErrors = subErrors[:limit]
+		}
 		return (&berrors.BoulderError{
 			Type:   berrors.RejectedIdentifier,
-			Detail: detail,
+			Detail: detail + ".)",
 		}).WithSubErrors(subErrors)
 	}
 	return nil
@@ -555,7 +1375,9 @@ func (pa *AuthorityImpl) checkWildcardBlocklist(domain string) error {
 		return fmt.Errorf("identifier policy not yet loaded")
 	}
 
-	if pa.wildcardFqdnBlocklist[domain] {
+	source, ok := pa.wildcardFqdnBlocklist[domain]
+	if ok {
+		pa.logBlocked("*."+domain, source, domain)
 		return errPolicyForbidden
 	}
 
@@ -570,33 +1392,114 @@ func (pa *AuthorityImpl) checkBlocklists(ident identifier.ACMEIdentifier) error
 		return fmt.Errorf("identifier policy not yet loaded")
 	}
 
+	source, entry, err := pa.matchBlocklists(ident)
+	if err != nil {
+		return err
+	}
+	if source != 0 {
+		pa.logBlocked(ident.Value, source, entry)
+		return errPolicyForbidden
+	}
+	return nil
+}
+
+// checkAllowlist returns errPolicyForbidden if an allowlist is loaded and the
+// given DNS identifier is neither one of its names nor a subdomain of one.
+func (pa *AuthorityImpl) checkAllowlist(ident identifier.ACMEIdentifier) error {
+	if ident.Type != identifier.TypeDNS {
+		return nil
+	}
+
+	pa.blocklistMu.RLock()
+	defer pa.blocklistMu.RUnlock()
+
+	if pa.allowedNames == nil {
+		return nil
+	}
+	for _, suffix := range labelwiseSuffixes(ident.Value) {
+		if pa.allowedNames[suffix] {
+			return nil
+		}
+	}
+	pa.log.Infof("identifier %q forbidden by absence from AllowedNames", ident.Value)
+	return errPolicyForbidden
+}
+
+// matchBlocklists returns the source and value of the blocklist entry which
+// covers the given identifier, or a zero source if no entry matches. The
+// caller must hold blocklistMu.
+func (pa *AuthorityImpl) matchBlocklists(ident identifier.ACMEIdentifier) (blocklistSource, string, error) {
 	switch ident.Type {
 	case identifier.TypeDNS:
-		labels := strings.Split(ident.Value, ".")
-		for i := range labels {
-			joined := strings.Join(labels[i:], ".")
-			if pa.domainBlocklist[joined] {
-				return errPolicyForbidden
+		for _, suffix := range labelwiseSuffixes(ident.Value) {
+			source, ok := pa.domainBlocklist[suffix]
+			if ok {
+				return source, suffix, nil
 			}
 		}
 
-		if pa.fqdnBlocklist[ident.Value] {
-			return errPolicyForbidden
+		source, ok := pa.fqdnBlocklist[ident.Value]
+		if ok {
+			return source, ident.Value, nil
+		}
+
+		for _, re := range pa.regexBlocklist {
+			if re.MatchString(ident.Value) {
+				return sourceRegex, re.String(), nil
+			}
 		}
 	case identifier.TypeIP:
 		ip, err := netip.ParseAddr(ident.Value)
 		if err != nil {
-			return errIPInvalid
+			return 0, "", errIPInvalid
 		}
 		for _, prefix := range pa.ipPrefixBlocklist {
 			if prefix.Contains(ip.WithZone("")) {
-				return errPolicyForbidden
+				return sourceAdminPrefix, prefix.String(), nil
 			}
 		}
 	default:
-		return errUnsupportedIdent
+		return 0, "", errUnsupportedIdent
 	}
-	return nil
+	return 0, "", nil
+}
+
+// logBlocked records which blocklist, and which entry in it, caused the given
+// identifier value to be rejected. The Subscriber-facing error is the same
+// regardless of source.
+func (pa *AuthorityImpl) logBlocked(value string, source blocklistSource, entry string) {
+	pa.log.Infof("identifier %q forbidden by %s entry %q", value, source, entry)
+}
+
+// labelwiseSuffixes returns the given domain name and each of its label-wise
+// suffixes, from longest to shortest. For example, "www.example.com" yields
+// "www.example.com", "example.com", and "com".
+func labelwiseSuffixes(domain string) []string {
+	labels := strings.Split(domain, ".")
+	suffixes := make([]string, 0, len(labels))
+	for i := range labels {
+		suffixes = append(suffixes, strings.Join(labels[i:], "."))
+	}
+	return suffixes
+}
+
+// WouldBlock returns the subset of the provided identifiers which would be
+// blocked if entry were added to the HighRiskBlockedNames (or
+// AdminBlockedNames) list, using the same label-wise suffix matching as the
+// live blocklists. Only DNS identifiers can be matched. This is intended for
+// vetting a prospective blocklist entry; it does not read or modify the
+// currently loaded blocklists.
+func (pa *AuthorityImpl) WouldBlock(entry string, idents identifier.ACMEIdentifiers) []identifier.ACMEIdentifier {
+	var blocked []identifier.ACMEIdentifier
+	for _, ident := range idents {
+		if ident.Type != identifier.TypeDNS {
+			continue
+		}
+		if slices.Contains(labelwiseSuffixes(ident.Value), entry) {
+			blocked = append(blocked, ident)
+		}
+	}
+	return blocked
 }
 
 // ChallengeTypesFor determines which challenge types are acceptable for the
@@ -607,22 +1510,7 @@ func (pa *AuthorityImpl) checkBlocklists(ident identifier.ACMEIdentifier) error
 func (pa *AuthorityImpl) ChallengeTypesFor(ident identifier.ACMEIdentifier) ([]core.AcmeChallenge, error) {
 	switch ident.Type {
 	case identifier.TypeDNS:
-		// If the identifier is for a DNS wildcard name we only provide DNS-01,
-		// DNS-ACCOUNT-01, or DNS-PERSIST-01 challenges, to comply with the BRs
-		// Sections 3.2.2.4.19 and 3.2.2.4.20 stating that ACME HTTP-01 and
-		// TLS-ALPN-01 are not suitable for validating Wildcard Domains.
-		if strings.HasPrefix(ident.Value, "*.") {
-			challenges := []core.AcmeChallenge{core.ChallengeTypeDNS01}
-			if features.Get().DNSAccount01Enabled {
-				challenges = append(challenges, core.ChallengeTypeDNSAccount01)
-			}
-			if features.Get().DNSPersist01Enabled {
-				challenges = append(challenges, core.ChallengeTypeDNSPersist01)
-			}
-			return challenges, nil
-		}
-
-		// Return all challenge types we support for non-wildcard DNS identifiers.
+		// Start with all challenge types we support for DNS identifiers.
 		challenges := []core.AcmeChallenge{
 			core.ChallengeTypeHTTP01,
 			core.ChallengeTypeDNS01,
@@ -634,6 +1522,14 @@ func (pa *AuthorityImpl) ChallengeTypesFor(ident identifier.ACMEIdentifier) ([]c
 		if features.Get().DNSPersist01Enabled {
 			challenges = append(challenges, core.ChallengeTypeDNSPersist01)
 		}
+
+		// If the identifier is for a DNS wildcard name we only provide those
+		// which are valid for wildcards.
+		if strings.HasPrefix(ident.Value, "*.") {
+			challenges = slices.DeleteFunc(challenges, func(chall core.AcmeChallenge) bool {
+				return !IsChallengeValidForWildcard(chall)
+			})
+		}
 		return challenges, nil
 	case identifier.TypeIP:
 		// Only HTTP-01 and TLS-ALPN-01 are suitable for IP address identifiers
@@ -649,6 +1545,32 @@ func (pa *AuthorityImpl) ChallengeTypesFor(ident identifier.ACMEIdentifier) ([]c
 	}
 }
 
+// IsChallengeValidForWildcard returns whether the given challenge type may be
+// used to validate a wildcard DNS identifier. Only the DNS-based challenge types
+// are, to comply with the BRs Sections 3.2.2.4.19 and 3.2.2.4.20 stating that
+// ACME HTTP-01 and TLS-ALPN-01 are not suitable for validating Wildcard
+// Domains.
+func IsChallengeValidForWildcard(chall core.AcmeChallenge) bool {
+	switch chall {
+	case core.ChallengeTypeDNS01, core.ChallengeTypeDNSAccount01, core.ChallengeTypeDNSPersist01:
+		return true
+	default:
+		return false
+	}
+}
+
+// ChallengeApplicable returns whether the given challenge type is one of those
+// returned by ChallengeTypesFor for the given identifier. Like
+// ChallengeTypesFor, it does not consider whether the challenge type is
+// enabled. It returns false for unrecognized identifier types.
+func (pa *AuthorityImpl) ChallengeApplicable(ident identifier.ACMEIdentifier, chall core.AcmeChallenge) bool {
+	challTypes, err := pa.ChallengeTypesFor(ident)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(challTypes, chall)
+}
+
 // ChallengeTypeEnabled returns whether the specified challenge type is enabled
 func (pa *AuthorityImpl) ChallengeTypeEnabled(t core.AcmeChallenge) bool {
 	pa.blocklistMu.RLock()
@@ -656,6 +1578,21 @@ func (pa *AuthorityImpl) ChallengeTypeEnabled(t core.AcmeChallenge) bool {
 	return pa.enabledChallenges[t]
 }
 
+// EnabledChallenges returns the enabled challenge types, sorted by name.
+func (pa *AuthorityImpl) EnabledChallenges() []core.AcmeChallenge {
+	pa.blocklistMu.RLock()
+	defer pa.blocklistMu.RUnlock()
+
+	var enabled []core.AcmeChallenge
+	for chall, ok := range pa.enabledChallenges {
+		if ok {
+			enabled = append(enabled, chall)
+		}
+	}
+	slices.Sort(enabled)
+	return enabled
+}
+
 // CheckAuthzChallenges determines that an authorization was fulfilled by a
 // challenge that is currently enabled and was appropriate for the kind of
 // identifier in the authorization.
@@ -669,6 +1606,10 @@ func (pa *AuthorityImpl) CheckAuthzChallenges(authz *core.Authorization) error {
 		return errors.New("authorization fulfilled by disabled challenge type")
 	}
 
+	if authz.Identifier.Type == identifier.TypeDNS && strings.HasPrefix(authz.Identifier.Value, "*.") && !IsChallengeValidForWildcard(chall) {
+		return errors.New("wildcard authorization fulfilled by inapplicable challenge type")
+	}
+
 	challTypes, err := pa.ChallengeTypesFor(authz.Identifier)
 	if err != nil {
 		return err
@@ -1,10 +1,16 @@
 package policy
 
 import (
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"errors"
 	"fmt"
+	"math/big"
+	"net"
 	"net/netip"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -276,6 +282,99 @@ func TestWillingToIssue(t *testing.T) {
 	}
 }
 
+func TestWouldBlock(t *testing.T) {
+	t.Parallel()
+	pa := paImpl(t)
+
+	idents := identifier.ACMEIdentifiers{
+		identifier.NewDNS("example.com"),
+		identifier.NewDNS("www.example.com"),
+		identifier.NewDNS("deeply.nested.www.example.com"),
+		identifier.NewDNS("*.www.example.com"),
+		identifier.NewDNS("example.net"),
+		identifier.NewDNS("notwww.example.com"),
+		identifier.NewDNS("wwwexample.com"),
+		identifier.NewIP(netip.MustParseAddr("64.112.117.1")),
+	}
+
+	blocked := pa.WouldBlock("www.example.com", idents)
+	test.AssertDeepEquals(t, blocked, []identifier.ACMEIdentifier{
+		identifier.NewDNS("www.example.com"),
+		identifier.NewDNS("deeply.nested.www.example.com"),
+		identifier.NewDNS("*.www.example.com"),
+	})
+
+	blocked = pa.WouldBlock("example.org", idents)
+	test.AssertEquals(t, len(blocked), 0)
+
+	// Evaluating a prospective entry must not affect the live blocklists.
+	err := pa.processIdentPolicy(blockedIdentsPolicy{
+		HighRiskBlockedNames: []string{"example.org"},
+		ExactBlockedNames:    []string{"highvalue.example.org"},
+	})
+	test.AssertNotError(t, err, "Couldn't load policy contents")
+	_ = pa.WouldBlock("example.com", idents)
+	err = pa.WillingToIssue(identifier.ACMEIdentifiers{identifier.NewDNS("www.example.com")})
+	test.AssertNotError(t, err, "WouldBlock should not have modified the live blocklists")
+}
+
+func TestWithReservedPrefixes(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(nil, nil, blog.NewMock(), WithReservedPrefixes([]string{"not-a-prefix"}))
+	test.AssertError(t, err, "New should fail with a malformed reserved prefix")
+
+	pa, err := New(
+		map[identifier.IdentifierType]bool{identifier.TypeDNS: true, identifier.TypeIP: true},
+		nil,
+		blog.NewMock(),
+		WithReservedPrefixes([]string{"64.112.117.0/24", "2602:80a:6000:bad::1/64"}),
+	)
+	test.AssertNotError(t, err, "Couldn't create policy implementation")
+	err = pa.processIdentPolicy(blockedIdentsPolicy{
+		HighRiskBlockedNames: []string{"example.org"},
+		ExactBlockedNames:    []string{"highvalue.example.org"},
+	})
+	test.AssertNotError(t, err, "Couldn't load policy contents")
+
+	testCases := []struct {
+		ip      string
+		wantErr bool
+	}{
+		// Reserved only via the supplemental set.
+		{ip: "64.112.117.66", wantErr: true},
+		{ip: "2602:80a:6000:bad::66", wantErr: true},
+		// Reserved by the built-in IANA registries.
+		{ip: "10.0.0.1", wantErr: true},
+		// Not reserved at all.
+		{ip: "64.112.118.66", wantErr: false},
+		{ip: "2602:80a:6000:b0b::66", wantErr: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.ip, func(t *testing.T) {
+			t.Parallel()
+			err := pa.ValidIP(tc.ip)
+			if tc.wantErr {
+				test.AssertError(t, err, "ValidIP should have failed")
+				test.AssertContains(t, err.Error(), "reserved address block")
+			} else {
+				test.AssertNotError(t, err, "ValidIP should have succeeded")
+			}
+
+			err = pa.WillingToIssue(identifier.ACMEIdentifiers{identifier.NewIP(netip.MustParseAddr(tc.ip))})
+			if tc.wantErr {
+				test.AssertErrorIs(t, err, berrors.RejectedIdentifier)
+				test.AssertContains(t, err.Error(), "reserved address block")
+			} else {
+				test.AssertNotError(t, err, "WillingToIssue should have succeeded")
+			}
+		})
+	}
+
+	// The package-level ValidIP is unaffected by the supplemental set.
+	test.AssertNotError(t, ValidIP("64.112.117.66"), "package-level ValidIP should not consult supplemental prefixes")
+}
+
 func TestWillingToIssue_Wildcards(t *testing.T) {
 	bannedDomains := []string{
 		"zombo.gov.us",
@@ -585,6 +684,62 @@ func TestChallengeTypesFor(t *testing.T) {
 	})
 }
 
+func TestChallengeApplicable(t *testing.T) {
+	t.Parallel()
+	pa := paImpl(t)
+
+	testCases := []struct {
+		name  string
+		ident identifier.ACMEIdentifier
+		chall core.AcmeChallenge
+		want  bool
+	}{
+		{
+			name:  "http-01 for ip",
+			ident: identifier.NewIP(netip.MustParseAddr("64.112.117.1")),
+			chall: core.ChallengeTypeHTTP01,
+			want:  true,
+		},
+		{
+			name:  "tls-alpn-01 for ip",
+			ident: identifier.NewIP(netip.MustParseAddr("2602:80a:6000::1")),
+			chall: core.ChallengeTypeTLSALPN01,
+			want:  true,
+		},
+		{
+			name:  "dns-01 for ip",
+			ident: identifier.NewIP(netip.MustParseAddr("64.112.117.1")),
+			chall: core.ChallengeTypeDNS01,
+			want:  false,
+		},
+		{
+			name:  "dns-01 for dns",
+			ident: identifier.NewDNS("example.com"),
+			chall: core.ChallengeTypeDNS01,
+			want:  true,
+		},
+		{
+			name:  "http-01 for dns wildcard",
+			ident: identifier.NewDNS("*.example.com"),
+			chall: core.ChallengeTypeHTTP01,
+			want:  false,
+		},
+		{
+			name:  "unrecognized identifier type",
+			ident: identifier.ACMEIdentifier{Type: "fnord", Value: "example.com"},
+			chall: core.ChallengeTypeHTTP01,
+			want:  false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			test.AssertEquals(t, pa.ChallengeApplicable(tc.ident, tc.chall), tc.want)
+		})
+	}
+}
+
 // TestMalformedExactBlocklist tests that loading a YAML policy file with an
 // invalid exact blocklist entry will fail as expected.
 func TestMalformedExactBlocklist(t *testing.T) {
@@ -633,6 +788,44 @@ func TestValidEmailError(t *testing.T) {
 	test.AssertEquals(t, err.Error(), "contact email has invalid domain: Domain name contains an invalid character")
 }
 
+func TestValidEmails(t *testing.T) {
+	t.Parallel()
+
+	err := ValidEmails("alice@letsencrypt.org, bob@letsencrypt.org,")
+	test.AssertNotError(t, err, "all addresses are valid")
+
+	err = ValidEmails("alice@letsencrypt.org, bob@example.com")
+	test.AssertErrorIs(t, err, berrors.InvalidEmail)
+	bErr, ok := errors.AsType[*berrors.BoulderError](err)
+	test.Assert(t, ok, "error should be a BoulderError")
+	test.AssertEquals(t, len(bErr.SubErrors), 1)
+	test.AssertEquals(t, bErr.SubErrors[0].Detail, "contact email 2: contact email has forbidden domain \"example.com\"")
+	test.AssertEquals(t, bErr.Detail, bErr.SubErrors[0].Detail)
+
+	err = ValidEmails("(๑•́ ω •̀๑), alice@letsencrypt.org, bob@-foobar.com")
+	test.AssertErrorIs(t, err, berrors.InvalidEmail)
+	bErr, ok = errors.AsType[*berrors.BoulderError](err)
+	test.Assert(t, ok, "error should be a BoulderError")
+	test.AssertEquals(t, len(bErr.SubErrors), 2)
+	test.AssertEquals(t, bErr.SubErrors[0].Detail, "contact email 1: unable to parse email address")
+	test.AssertContains(t, bErr.SubErrors[1].Detail, "contact email 3: contact email has invalid domain")
+	test.AssertContains(t, bErr.Detail, "and 1 more problems")
+
+	err = ValidEmails(" , ")
+	test.AssertErrorIs(t, err, berrors.InvalidEmail)
+	test.AssertEquals(t, err.Error(), "no contact email addresses provided")
+}
+
+func TestIsChallengeValidForWildcard(t *testing.T) {
+	t.Parallel()
+
+	test.Assert(t, IsChallengeValidForWildcard(core.ChallengeTypeDNS01), "dns-01 should be valid for wildcards")
+	test.Assert(t, IsChallengeValidForWildcard(core.ChallengeTypeDNSAccount01), "dns-account-01 should be valid for wildcards")
+	test.Assert(t, IsChallengeValidForWildcard(core.ChallengeTypeDNSPersist01), "dns-persist-01 should be valid for wildcards")
+	test.Assert(t, !IsChallengeValidForWildcard(core.ChallengeTypeHTTP01), "http-01 should not be valid for wildcards")
+	test.Assert(t, !IsChallengeValidForWildcard(core.ChallengeTypeTLSALPN01), "tls-alpn-01 should not be valid for wildcards")
+}
+
 func TestCheckAuthzChallenges(t *testing.T) {
 	t.Parallel()
 
@@ -681,7 +874,22 @@ func TestCheckAuthzChallenges(t *testing.T) {
 				Identifier: identifier.NewDNS("*.example.com"),
 				Challenges: []core.Challenge{{Type: core.ChallengeTypeHTTP01, Status: core.StatusValid}},
 			},
-			wantErr: "inapplicable challenge type",
+			wantErr: "wildcard authorization fulfilled by inapplicable challenge type",
+		},
+		{
+			name: "wildcard solved by tls-alpn-01",
+			authz: core.Authorization{
+				Identifier: identifier.NewDNS("*.example.com"),
+				Challenges: []core.Challenge{{Type: core.ChallengeTypeTLSALPN01, Status: core.StatusValid}},
+			},
+			wantErr: "wildcard authorization fulfilled by inapplicable challenge type",
+		},
+		{
+			name: "valid wildcard authz",
+			authz: core.Authorization{
+				Identifier: identifier.NewDNS("*.example.com"),
+				Challenges: []core.Challenge{{Type: core.ChallengeTypeDNS01, Status: core.StatusValid}},
+			},
 		},
 		{
 			name: "valid authz",
@@ -818,3 +1026,806 @@ func TestWillingToIssue_IdentifierType(t *testing.T) {
 		})
 	}
 }
+
+func TestBlocklistSource(t *testing.T) {
+	t.Parallel()
+
+	log := blog.NewMock()
+	pa, err := New(
+		map[identifier.IdentifierType]bool{identifier.TypeDNS: true, identifier.TypeIP: true},
+		map[core.AcmeChallenge]bool{core.ChallengeTypeDNS01: true},
+		log,
+	)
+	test.AssertNotError(t, err, "Couldn't create policy implementation")
+
+	err = pa.processIdentPolicy(blockedIdentsPolicy{
+		HighRiskBlockedNames: []string{"highrisk.com", "both.com"},
+		AdminBlockedNames:    []string{"admin.com", "both.com"},
+		ExactBlockedNames:    []string{"exact.example.com"},
+		AdminBlockedPrefixes: []string{"64.112.117.0/24"},
+	})
+	test.AssertNotError(t, err, "Couldn't load policy contents")
+
+	testCases := []struct {
+		ident  identifier.ACMEIdentifier
+		source blocklistSource
+		entry  string
+	}{
+		{identifier.NewDNS("www.highrisk.com"), sourceHighRisk, "highrisk.com"},
+		{identifier.NewDNS("admin.com"), sourceAdmin, "admin.com"},
+		{identifier.NewDNS("both.com"), sourceHighRisk, "both.com"},
+		{identifier.NewDNS("exact.example.com"), sourceExact, "exact.example.com"},
+		{identifier.NewIP(netip.MustParseAddr("64.112.117.66")), sourceAdminPrefix, "64.112.117.0/24"},
+		{identifier.NewDNS("example.org"), 0, ""},
+	}
+	for _, tc := range testCases {
+		source, entry, err := pa.matchBlocklists(tc.ident)
+		test.AssertNotError(t, err, "matchBlocklists failed")
+		test.AssertEquals(t, source, tc.source)
+		test.AssertEquals(t, entry, tc.entry)
+	}
+
+	// Rejections should be logged with the list that caused them.
+	log.Clear()
+	err = pa.WillingToIssue(identifier.ACMEIdentifiers{identifier.NewDNS("www.admin.com")})
+	test.AssertErrorIs(t, err, berrors.RejectedIdentifier)
+	test.AssertEquals(t, len(log.GetAllMatching(`"www.admin.com" forbidden by AdminBlockedNames entry "admin.com"`)), 1)
+
+	log.Clear()
+	err = pa.WillingToIssue(identifier.ACMEIdentifiers{identifier.NewDNS("*.example.com")})
+	test.AssertErrorIs(t, err, berrors.RejectedIdentifier)
+	test.AssertEquals(t, len(log.GetAllMatching(`"\*.example.com" forbidden by ExactBlockedNames entry "example.com"`)), 1)
+}
+
+func TestAllowedNames(t *testing.T) {
+	t.Parallel()
+
+	enabled := map[identifier.IdentifierType]bool{identifier.TypeDNS: true, identifier.TypeIP: true}
+	pa, err := New(enabled, nil, blog.NewMock())
+	test.AssertNotError(t, err, "Couldn't create policy implementation")
+
+	err = pa.processIdentPolicy(blockedIdentsPolicy{
+		AllowedNames:         []string{"corp.example.com", "example.net"},
+		HighRiskBlockedNames: []string{"secret.corp.example.com"},
+	})
+	test.AssertNotError(t, err, "Couldn't load policy contents")
+
+	testCases := []struct {
+		name    string
+		allowed bool
+	}{
+		{"corp.example.com", true},
+		{"www.corp.example.com", true},
+		{"*.corp.example.com", true},
+		{"a.b.example.net", true},
+		// Not a label-wise suffix of an allowed name.
+		{"notcorp.example.com", false},
+		{"example.com", false},
+		{"corp.example.org", false},
+		// The blocklist still wins.
+		{"secret.corp.example.com", false},
+		{"www.secret.corp.example.com", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := pa.WillingToIssue(identifier.ACMEIdentifiers{identifier.NewDNS(tc.name)})
+			if tc.allowed {
+				test.AssertNotError(t, err, "name should be allowed")
+			} else {
+				test.AssertErrorIs(t, err, berrors.RejectedIdentifier)
+				test.AssertContains(t, err.Error(), errPolicyForbidden.Error())
+			}
+		})
+	}
+
+	// IP identifiers are not subject to the allowlist.
+	err = pa.WillingToIssue(identifier.ACMEIdentifiers{identifier.NewIP(netip.MustParseAddr("64.112.117.1"))})
+	test.AssertNotError(t, err, "IP identifier should not be subject to AllowedNames")
+
+	// Without AllowedNames, any name not on a blocklist is permitted.
+	err = pa.processIdentPolicy(blockedIdentsPolicy{
+		HighRiskBlockedNames: []string{"secret.corp.example.com"},
+	})
+	test.AssertNotError(t, err, "Couldn't load policy contents")
+	err = pa.WillingToIssue(identifier.ACMEIdentifiers{identifier.NewDNS("example.com")})
+	test.AssertNotError(t, err, "name should be allowed without an allowlist")
+}
+
+func TestClassifyRejection(t *testing.T) {
+	t.Parallel()
+
+	pa, err := New(map[identifier.IdentifierType]bool{identifier.TypeDNS: true}, nil, blog.NewMock())
+	test.AssertNotError(t, err, "Couldn't create policy implementation")
+	err = pa.processIdentPolicy(blockedIdentsPolicy{
+		HighRiskBlockedNames: []string{"blocked.com"},
+		ExactBlockedNames:    []string{"highvalue.example.com"},
+	})
+	test.AssertNotError(t, err, "Couldn't load policy contents")
+
+	testCases := []struct {
+		ident  identifier.ACMEIdentifier
+		reason string
+	}{
+		{identifier.NewDNS("example.org"), "ok"},
+		{identifier.NewDNS("Example.org"), "malformed"},
+		{identifier.NewDNS("bad..example.org"), "malformed"},
+		{identifier.NewIP(netip.MustParseAddr("64.112.117.1")), "disabled-type"},
+		{identifier.NewDNS("*.example.com"), "wildcard-blocklisted"},
+		{identifier.NewDNS("www.blocked.com"), "blocklisted"},
+		{identifier.NewDNS("highvalue.example.com"), "blocklisted"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.ident.Value, func(t *testing.T) {
+			reason, err := pa.ClassifyRejection(tc.ident)
+			test.AssertEquals(t, reason, tc.reason)
+			if tc.reason == "ok" {
+				test.AssertNotError(t, err, "identifier should be accepted")
+				return
+			}
+			test.AssertError(t, err, "identifier should be rejected")
+			// The error matches the one WillingToIssue would report.
+			test.AssertContains(t, pa.WillingToIssue(identifier.ACMEIdentifiers{tc.ident}).Error(), err.Error())
+		})
+	}
+}
+
+func TestRegexBlockedNames(t *testing.T) {
+	t.Parallel()
+
+	pa, err := New(map[identifier.IdentifierType]bool{identifier.TypeDNS: true}, nil, blog.NewMock())
+	test.AssertNotError(t, err, "Couldn't create policy implementation")
+
+	err = pa.processIdentPolicy(blockedIdentsPolicy{
+		RegexBlockedNames: []string{`^(www\d+\.evil\.com`},
+	})
+	test.AssertError(t, err, "Loaded an uncompilable regex without error")
+
+	err = pa.processIdentPolicy(blockedIdentsPolicy{
+		RegexBlockedNames: []string{`^www\d+\.evil\.com$`, `^MAIL-[a-z]+\.`},
+	})
+	test.AssertNotError(t, err, "Couldn't load policy contents")
+
+	testCases := []struct {
+		name    string
+		blocked bool
+	}{
+		{"www1.evil.com", true},
+		{"www123.evil.com", true},
+		{"*.www2.evil.com", false},
+		{"www.evil.com", false},
+		{"a.www1.evil.com", false},
+		{"www1.evil.com.example.com", false},
+		{"mail-relay.example.com", true},
+		{"mail.example.com", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := pa.WillingToIssue(identifier.ACMEIdentifiers{identifier.NewDNS(tc.name)})
+			if tc.blocked {
+				test.AssertErrorIs(t, err, berrors.RejectedIdentifier)
+				test.AssertContains(t, err.Error(), errPolicyForbidden.Error())
+			} else {
+				test.AssertNotError(t, err, "name should not be blocked")
+			}
+		})
+	}
+
+	source, entry, err := pa.matchBlocklists(identifier.NewDNS("www1.evil.com"))
+	test.AssertNotError(t, err, "matchBlocklists failed")
+	test.AssertEquals(t, source, sourceRegex)
+	test.AssertEquals(t, entry, `(?i)^www\d+\.evil\.com$`)
+}
+
+func TestAllIdentifiersOfType(t *testing.T) {
+	t.Parallel()
+
+	dnsOnly := identifier.ACMEIdentifiers{identifier.NewDNS("example.com"), identifier.NewDNS("example.net")}
+	mixed := identifier.ACMEIdentifiers{identifier.NewDNS("example.com"), identifier.NewIP(netip.MustParseAddr("64.112.117.1"))}
+
+	test.Assert(t, AllIdentifiersOfType(dnsOnly, identifier.TypeDNS), "DNS-only order should be all DNS")
+	test.Assert(t, !AllIdentifiersOfType(dnsOnly, identifier.TypeIP), "DNS-only order should not be all IP")
+	test.Assert(t, !AllIdentifiersOfType(mixed, identifier.TypeDNS), "mixed order should not be all DNS")
+	test.Assert(t, AllIdentifiersOfType(nil, identifier.TypeDNS), "empty order should be vacuously all DNS")
+}
+
+func TestCheckProfileIdentifierTypes(t *testing.T) {
+	t.Parallel()
+
+	pa, err := New(nil, nil, blog.NewMock(), WithSingleIdentifierTypeProfiles([]string{"shortlived"}), WithMaxSubErrors(2))
+	test.AssertNotError(t, err, "Couldn't create policy implementation")
+
+	dnsAndIP := []identifier.IdentifierType{identifier.TypeDNS, identifier.TypeIP}
+	dnsOnly := []identifier.IdentifierType{identifier.TypeDNS}
+	mixed := identifier.ACMEIdentifiers{identifier.NewDNS("example.com"), identifier.NewIP(netip.MustParseAddr("64.112.117.1"))}
+	ipOnly := identifier.ACMEIdentifiers{identifier.NewIP(netip.MustParseAddr("64.112.117.1"))}
+
+	err = pa.CheckProfileIdentifierTypes("shortlived", dnsAndIP, mixed)
+	test.AssertErrorIs(t, err, berrors.Malformed)
+
+	err = pa.CheckProfileIdentifierTypes("shortlived", dnsAndIP, ipOnly)
+	test.AssertNotError(t, err, "single-type order should be accepted under single-type profile")
+
+	err = pa.CheckProfileIdentifierTypes("classic", dnsAndIP, mixed)
+	test.AssertNotError(t, err, "mixed order should be accepted under other profiles")
+
+	// An IP identifier is rejected under a DNS-only profile.
+	err = pa.CheckProfileIdentifierTypes("classic", dnsOnly, ipOnly)
+	test.AssertErrorIs(t, err, berrors.RejectedIdentifier)
+	test.AssertContains(t, err.Error(), `Cannot issue for "64.112.117.1": Profile "classic" does not permit IP address identifiers`)
+
+	// Each rejected identifier gets its own sub-error, subject to the limit
+	// set by WithMaxSubErrors.
+	ips := identifier.ACMEIdentifiers{
+		identifier.NewDNS("example.com"),
+		identifier.NewIP(netip.MustParseAddr("64.112.117.1")),
+		identifier.NewIP(netip.MustParseAddr("64.112.117.2")),
+		identifier.NewIP(netip.MustParseAddr("64.112.117.3")),
+	}
+	err = pa.CheckProfileIdentifierTypes("classic", dnsOnly, ips)
+	test.AssertErrorIs(t, err, berrors.RejectedIdentifier)
+	test.AssertContains(t, err.Error(), "(and 2 more problems. Refer to sub-problems for more information; only the first 2 are included.)")
+	bErr, ok := errors.AsType[*berrors.BoulderError](err)
+	test.Assert(t, ok, "error should be a BoulderError")
+	test.AssertEquals(t, len(bErr.SubErrors), 2)
+	for i, subErr := range bErr.SubErrors {
+		test.AssertEquals(t, subErr.Identifier, ips[i+1])
+		test.AssertEquals(t, subErr.Type, berrors.RejectedIdentifier)
+	}
+}
+
+func TestWillingToIssue_LowercaseAssertion(t *testing.T) {
+	t.Parallel()
+
+	policy := blockedIdentsPolicy{
+		HighRiskBlockedNames: []string{"example.org"},
+	}
+	enabled := map[identifier.IdentifierType]bool{identifier.TypeDNS: true}
+
+	pa, err := New(enabled, nil, blog.NewMock())
+	test.AssertNotError(t, err, "Couldn't create policy implementation")
+	err = pa.processIdentPolicy(policy)
+	test.AssertNotError(t, err, "Couldn't load policy contents")
+
+	err = pa.WillingToIssue(identifier.ACMEIdentifiers{identifier.NewDNS("www.Example.com")})
+	test.AssertErrorIs(t, err, berrors.Malformed)
+	test.AssertContains(t, err.Error(), "must be lowercase")
+
+	err = pa.WillingToIssue(identifier.ACMEIdentifiers{identifier.NewDNS("www.example.com")})
+	test.AssertNotError(t, err, "lowercase identifier should be accepted")
+
+	pa, err = New(enabled, nil, blog.NewMock(), WithLowercaseAssertion(false))
+	test.AssertNotError(t, err, "Couldn't create policy implementation")
+	err = pa.processIdentPolicy(policy)
+	test.AssertNotError(t, err, "Couldn't load policy contents")
+
+	// Without the assertion, the identifier falls through to the regular
+	// character checks instead.
+	err = pa.WillingToIssue(identifier.ACMEIdentifiers{identifier.NewDNS("www.Example.com")})
+	test.AssertError(t, err, "uppercase identifier should still be rejected")
+	test.Assert(t, !strings.Contains(err.Error(), "must be lowercase"), "lowercase assertion should have been disabled")
+}
+
+func TestWithAllowedScripts(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(nil, nil, blog.NewMock(), WithAllowedScripts([]string{"Klingon"}))
+	test.AssertError(t, err, "New should fail with an unknown script")
+
+	policy := blockedIdentsPolicy{
+		HighRiskBlockedNames: []string{"example.org"},
+	}
+	enabled := map[identifier.IdentifierType]bool{identifier.TypeDNS: true}
+
+	pa, err := New(enabled, nil, blog.NewMock(), WithAllowedScripts([]string{"Latin", "Cyrillic"}))
+	test.AssertNotError(t, err, "Couldn't create policy implementation")
+	err = pa.processIdentPolicy(policy)
+	test.AssertNotError(t, err, "Couldn't load policy contents")
+
+	testCases := []struct {
+		desc    string
+		domain  string
+		wantErr bool
+	}{
+		{desc: "ASCII", domain: "www.example.com"},
+		{desc: "single-script Latin IDN", domain: "xn--bcher-kva.example.com"},
+		{desc: "single-script Cyrillic IDN", domain: "xn--e1afmkfd.example.com"},
+		// "pаypal" with a Cyrillic "а".
+		{desc: "mixed-script IDN", domain: "xn--pypal-4ve.example.com", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := pa.WillingToIssue(identifier.ACMEIdentifiers{identifier.NewDNS(tc.domain)})
+			if tc.wantErr {
+				test.AssertErrorIs(t, err, berrors.RejectedIdentifier)
+				test.AssertContains(t, err.Error(), errDisallowedScript.Error())
+			} else {
+				test.AssertNotError(t, err, "WillingToIssue should have succeeded")
+			}
+		})
+	}
+
+	// Only the configured scripts are allowed.
+	pa, err = New(enabled, nil, blog.NewMock(), WithAllowedScripts([]string{"Latin"}))
+	test.AssertNotError(t, err, "Couldn't create policy implementation")
+	err = pa.processIdentPolicy(policy)
+	test.AssertNotError(t, err, "Couldn't load policy contents")
+	err = pa.WillingToIssue(identifier.ACMEIdentifiers{identifier.NewDNS("xn--e1afmkfd.example.com")})
+	test.AssertErrorIs(t, err, berrors.RejectedIdentifier)
+
+	// Without the option, scripts are not restricted.
+	pa, err = New(enabled, nil, blog.NewMock())
+	test.AssertNotError(t, err, "Couldn't create policy implementation")
+	err = pa.processIdentPolicy(policy)
+	test.AssertNotError(t, err, "Couldn't load policy contents")
+	err = pa.WillingToIssue(identifier.ACMEIdentifiers{identifier.NewDNS("xn--pypal-4ve.example.com")})
+	test.AssertNotError(t, err, "mixed-script IDN should be accepted by default")
+}
+
+func TestWithLeadingUnderscoreLabels(t *testing.T) {
+	t.Parallel()
+
+	policy := blockedIdentsPolicy{
+		HighRiskBlockedNames: []string{"example.org"},
+	}
+	enabled := map[identifier.IdentifierType]bool{identifier.TypeDNS: true}
+
+	pa, err := New(enabled, nil, blog.NewMock())
+	test.AssertNotError(t, err, "Couldn't create policy implementation")
+	err = pa.processIdentPolicy(policy)
+	test.AssertNotError(t, err, "Couldn't load policy contents")
+
+	err = pa.WillingToIssue(identifier.ACMEIdentifiers{identifier.NewDNS("_dmarc.example.com")})
+	test.AssertErrorIs(t, err, berrors.RejectedIdentifier)
+	test.AssertContains(t, err.Error(), errLeadingUnderscore.Error())
+
+	pa, err = New(enabled, nil, blog.NewMock(), WithLeadingUnderscoreLabels(true))
+	test.AssertNotError(t, err, "Couldn't create policy implementation")
+	err = pa.processIdentPolicy(policy)
+	test.AssertNotError(t, err, "Couldn't load policy contents")
+
+	testCases := []struct {
+		domain  string
+		wantErr error
+	}{
+		{domain: "_dmarc.example.com"},
+		{domain: "www.example.com"},
+		// Only the leftmost label may begin with an underscore.
+		{domain: "www._dmarc.example.com", wantErr: errLeadingUnderscore},
+		{domain: "_dmarc._domainkey.example.com", wantErr: errLeadingUnderscore},
+		{domain: "_.example.com", wantErr: errLabelTooShort},
+		{domain: "_-dmarc.example.com", wantErr: errInvalidDNSCharacter},
+		{domain: "_dmarc.com", wantErr: errICANNTLD},
+		// The rest of the name is still subject to the blocklists.
+		{domain: "_dmarc.example.org", wantErr: errPolicyForbidden},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.domain, func(t *testing.T) {
+			err := pa.WillingToIssue(identifier.ACMEIdentifiers{identifier.NewDNS(tc.domain)})
+			if tc.wantErr != nil {
+				test.AssertError(t, err, "WillingToIssue should have failed")
+				test.AssertContains(t, err.Error(), tc.wantErr.Error())
+			} else {
+				test.AssertNotError(t, err, "WillingToIssue should have succeeded")
+			}
+		})
+	}
+
+	// The package-level checks are unaffected by the option.
+	test.AssertErrorIs(t, ValidDomain("_dmarc.example.com"), errLeadingUnderscore)
+}
+
+func TestWellFormedIdentifiersLenient(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		domain  string
+		wantErr error
+	}{
+		{domain: "_dmarc.example.com"},
+		{domain: "a.b.c.d.e.f.g.h.i.j.example.com"},
+		{domain: "*._dmarc.example.com", wantErr: errLeadingUnderscore},
+		{domain: "www._dmarc.example.com", wantErr: errLeadingUnderscore},
+		{domain: "www.zombo_com.com", wantErr: errInvalidDNSCharacter},
+		{domain: "example.invalid", wantErr: errNonPublic},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.domain, func(t *testing.T) {
+			err := WellFormedIdentifiersLenient(identifier.ACMEIdentifiers{identifier.NewDNS(tc.domain)})
+			if tc.wantErr != nil {
+				test.AssertError(t, err, "WellFormedIdentifiersLenient should have failed")
+				test.AssertContains(t, err.Error(), tc.wantErr.Error())
+			} else {
+				test.AssertNotError(t, err, "WellFormedIdentifiersLenient should have succeeded")
+			}
+		})
+	}
+}
+
+func TestMaxSubErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(nil, nil, blog.NewMock(), WithMaxSubErrors(-1))
+	test.AssertError(t, err, "negative max sub-errors should be rejected")
+
+	pa, err := New(map[identifier.IdentifierType]bool{identifier.TypeDNS: true}, nil, blog.NewMock(), WithMaxSubErrors(3))
+	test.AssertNotError(t, err, "Couldn't create policy implementation")
+
+	malformed := func(n int) identifier.ACMEIdentifiers {
+		var idents identifier.ACMEIdentifiers
+		for i := range n {
+			idents = append(idents, identifier.NewDNS(fmt.Sprintf("bad_%d.com", i)))
+		}
+		return idents
+	}
+
+	testCases := []struct {
+		name          string
+		count         int
+		wantSubErrors int
+		wantTruncated bool
+	}{
+		{name: "under cap", count: 2, wantSubErrors: 2},
+		{name: "at cap", count: 3, wantSubErrors: 3},
+		{name: "over cap", count: 5, wantSubErrors: 3, wantTruncated: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := pa.WillingToIssue(malformed(tc.count))
+			berr, ok := errors.AsType[*berrors.BoulderError](err)
+			test.Assert(t, ok, "expected a *BoulderError")
+			test.AssertEquals(t, len(berr.SubErrors), tc.wantSubErrors)
+			test.AssertContains(t, berr.Detail, fmt.Sprintf("(and %d more problems.", tc.count-1))
+			if tc.wantTruncated {
+				test.AssertContains(t, berr.Detail, "more information; only the first 3 are included.)")
+			} else {
+				test.AssertContains(t, berr.Detail, "more information.)")
+			}
+		})
+	}
+}
+
+func TestWithDecisionCache(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(nil, nil, blog.NewMock(), WithDecisionCache(-1))
+	test.AssertError(t, err, "New should fail with a negative cache size")
+
+	writePolicy := func(t *testing.T, path string, policy blockedIdentsPolicy) {
+		t.Helper()
+		policyBytes, err := yaml.Marshal(policy)
+		test.AssertNotError(t, err, "Couldn't serialize policy")
+		err = os.WriteFile(path, policyBytes, 0640)
+		test.AssertNotError(t, err, "Couldn't write policy file")
+	}
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	writePolicy(t, path, blockedIdentsPolicy{
+		HighRiskBlockedNames: []string{"example.org"},
+		ExactBlockedNames:    []string{"highvalue.example.org"},
+	})
+
+	log := blog.NewMock()
+	pa, err := New(map[identifier.IdentifierType]bool{identifier.TypeDNS: true}, nil, log, WithDecisionCache(10))
+	test.AssertNotError(t, err, "Couldn't create policy implementation")
+	err = pa.LoadIdentPolicyFile(path)
+	test.AssertNotError(t, err, "Couldn't load policy file")
+
+	allowed := identifier.ACMEIdentifiers{identifier.NewDNS("www.example.com")}
+	blocked := identifier.ACMEIdentifiers{identifier.NewDNS("www.example.org")}
+	test.AssertNotError(t, pa.WillingToIssue(allowed), "WillingToIssue should have succeeded")
+	test.AssertErrorIs(t, pa.WillingToIssue(blocked), berrors.RejectedIdentifier)
+
+	// Rejections aren't cached, so each one is logged.
+	test.AssertErrorIs(t, pa.WillingToIssue(blocked), berrors.RejectedIdentifier)
+	test.AssertEquals(t, len(log.GetAllMatching(`identifier "www.example.org" forbidden by HighRiskBlockedNames`)), 2)
+
+	// Swap out the blocklists without changing the policy hash. Cached
+	// decisions should be returned without consulting the new blocklists,
+	// while rejections are re-evaluated against them.
+	pa.blocklistMu.Lock()
+	pa.domainBlocklist = map[string]blocklistSource{"example.com": sourceAdmin}
+	pa.blocklistMu.Unlock()
+	test.AssertNotError(t, pa.WillingToIssue(allowed), "cached decision should have been used")
+	test.AssertNotError(t, pa.WillingToIssue(blocked), "rejection should not have been cached")
+
+	// Loading a changed policy file invalidates cached decisions.
+	writePolicy(t, path, blockedIdentsPolicy{
+		HighRiskBlockedNames: []string{"example.com"},
+		ExactBlockedNames:    []string{"highvalue.example.com"},
+	})
+	err = pa.LoadIdentPolicyFile(path)
+	test.AssertNotError(t, err, "Couldn't reload policy file")
+	test.AssertErrorIs(t, pa.WillingToIssue(allowed), berrors.RejectedIdentifier)
+	test.AssertNotError(t, pa.WillingToIssue(blocked), "WillingToIssue should have succeeded after reload")
+}
+
+func TestWithMaxDNSNameLength(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(nil, nil, blog.NewMock(), WithMaxDNSNameLength(254))
+	test.AssertError(t, err, "New should fail with a limit above 253")
+	_, err = New(nil, nil, blog.NewMock(), WithMaxDNSNameLength(-1))
+	test.AssertError(t, err, "New should fail with a negative limit")
+
+	pa, err := New(map[identifier.IdentifierType]bool{identifier.TypeDNS: true}, nil, blog.NewMock(), WithMaxDNSNameLength(20))
+	test.AssertNotError(t, err, "Couldn't create policy implementation")
+	err = pa.processIdentPolicy(blockedIdentsPolicy{})
+	test.AssertNotError(t, err, "Couldn't load policy contents")
+
+	// 20 bytes exactly.
+	err = pa.WillingToIssue(identifier.ACMEIdentifiers{identifier.NewDNS("cdefghij.example.com")})
+	test.AssertNotError(t, err, "name at the configured limit should be accepted")
+
+	err = pa.WillingToIssue(identifier.ACMEIdentifiers{identifier.NewDNS("www.abcdefghij.example.com")})
+	test.AssertErrorIs(t, err, berrors.RejectedIdentifier)
+	test.AssertContains(t, err.Error(), "configured maximum of 20 bytes")
+
+	// The wildcard label counts towards the limit.
+	err = pa.WillingToIssue(identifier.ACMEIdentifiers{identifier.NewDNS("*.cdefghij.example.com")})
+	test.AssertErrorIs(t, err, berrors.RejectedIdentifier)
+	test.AssertContains(t, err.Error(), "configured maximum of 20 bytes")
+
+	// The limit is enforced by the shared validation path.
+	err = validNonWildcardDomain("www.abcdefghij.example.com", domainConstraints{maxLength: 20, maxLabels: maxLabels})
+	test.AssertContains(t, err.Error(), "configured maximum of 20 bytes")
+	err = validNonWildcardDomain("www.abcdefghij.example.com", defaultDomainConstraints)
+	test.AssertNotError(t, err, "name within 253 bytes should be accepted by default")
+}
+
+func TestWithMaxLabels(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(nil, nil, blog.NewMock(), WithMaxLabels(1))
+	test.AssertError(t, err, "New should fail with a limit below 2")
+	_, err = New(nil, nil, blog.NewMock(), WithMaxLabels(128))
+	test.AssertError(t, err, "New should fail with a limit above 127")
+
+	pa, err := New(map[identifier.IdentifierType]bool{identifier.TypeDNS: true}, nil, blog.NewMock(), WithMaxLabels(12))
+	test.AssertNotError(t, err, "Couldn't create policy implementation")
+	err = pa.processIdentPolicy(blockedIdentsPolicy{})
+	test.AssertNotError(t, err, "Couldn't load policy contents")
+
+	// 12 labels.
+	err = pa.WillingToIssue(identifier.ACMEIdentifiers{identifier.NewDNS("a.b.c.d.e.f.g.h.i.j.example.com")})
+	test.AssertNotError(t, err, "name with 12 labels should be accepted")
+	err = pa.WillingToIssue(identifier.ACMEIdentifiers{identifier.NewDNS("*.b.c.d.e.f.g.h.i.j.example.com")})
+	test.AssertNotError(t, err, "wildcard name with 12 labels should be accepted")
+
+	// 13 labels.
+	err = pa.WillingToIssue(identifier.ACMEIdentifiers{identifier.NewDNS("a.b.c.d.e.f.g.h.i.j.k.example.com")})
+	test.AssertErrorIs(t, err, berrors.RejectedIdentifier)
+	test.AssertContains(t, err.Error(), "more than 12 labels")
+
+	// The default limit still applies to ValidDomain.
+	err = ValidDomain("a.b.c.d.e.f.g.h.i.j.example.com")
+	test.AssertContains(t, err.Error(), errTooManyLabels.Error())
+}
+
+func TestEnabledChallenges(t *testing.T) {
+	t.Parallel()
+
+	pa, err := New(nil, map[core.AcmeChallenge]bool{
+		core.ChallengeTypeTLSALPN01:    true,
+		core.ChallengeTypeHTTP01:       true,
+		core.ChallengeTypeDNS01:        false,
+		core.ChallengeTypeDNSAccount01: true,
+	}, blog.NewMock())
+	test.AssertNotError(t, err, "Couldn't create policy implementation")
+
+	want := []core.AcmeChallenge{
+		core.ChallengeTypeDNSAccount01,
+		core.ChallengeTypeHTTP01,
+		core.ChallengeTypeTLSALPN01,
+	}
+	for range 10 {
+		test.AssertDeepEquals(t, pa.EnabledChallenges(), want)
+	}
+
+	pa, err = New(nil, nil, blog.NewMock())
+	test.AssertNotError(t, err, "Couldn't create policy implementation")
+	test.AssertEquals(t, len(pa.EnabledChallenges()), 0)
+}
+
+func TestCheckIdentifiers(t *testing.T) {
+	t.Parallel()
+
+	pa := paImpl(t)
+	err := pa.processIdentPolicy(blockedIdentsPolicy{
+		HighRiskBlockedNames: []string{"example.org"},
+		ExactBlockedNames:    []string{"highvalue.example.com"},
+		AdminBlockedPrefixes: []string{"64.112.117.0/24"},
+	})
+	test.AssertNotError(t, err, "Couldn't load policy contents")
+
+	results := pa.CheckIdentifiers(identifier.ACMEIdentifiers{
+		identifier.NewDNS("www.example.com"),
+		identifier.NewIP(netip.MustParseAddr("9.9.9.9")),
+		identifier.NewDNS("www.example.org"),
+		identifier.NewDNS("highvalue.example.com"),
+		identifier.NewIP(netip.MustParseAddr("64.112.117.1")),
+		identifier.NewDNS("bad..example.com"),
+		identifier.NewDNS("UPPER.example.com"),
+	})
+	test.AssertEquals(t, len(results), 7)
+
+	test.AssertNotError(t, results["www.example.com"], "allowed DNS identifier should have no error")
+	test.AssertNotError(t, results["9.9.9.9"], "allowed IP identifier should have no error")
+	test.AssertErrorIs(t, results["www.example.org"], berrors.RejectedIdentifier)
+	test.AssertErrorIs(t, results["highvalue.example.com"], berrors.RejectedIdentifier)
+	test.AssertErrorIs(t, results["64.112.117.1"], berrors.RejectedIdentifier)
+	test.AssertErrorIs(t, results["bad..example.com"], berrors.Malformed)
+	test.AssertErrorIs(t, results["UPPER.example.com"], berrors.Malformed)
+
+	// Errors are not combined, so they carry no sub-errors.
+	berr, ok := errors.AsType[*berrors.BoulderError](results["www.example.org"])
+	test.Assert(t, ok, "expected a BoulderError")
+	test.AssertEquals(t, len(berr.SubErrors), 0)
+}
+
+func TestPartitionIssuable(t *testing.T) {
+	t.Parallel()
+
+	pa := paImpl(t)
+	err := pa.processIdentPolicy(blockedIdentsPolicy{
+		HighRiskBlockedNames: []string{"example.org"},
+		ExactBlockedNames:    []string{"highvalue.example.com"},
+		AdminBlockedPrefixes: []string{"64.112.117.0/24"},
+	})
+	test.AssertNotError(t, err, "Couldn't load policy contents")
+
+	allowed, rejected := pa.PartitionIssuable(identifier.ACMEIdentifiers{
+		identifier.NewDNS("www.example.com"),
+		identifier.NewDNS("www.example.org"),
+		identifier.NewIP(netip.MustParseAddr("9.9.9.9")),
+		identifier.NewIP(netip.MustParseAddr("64.112.117.1")),
+		identifier.NewDNS("bad..example.com"),
+	})
+	test.AssertDeepEquals(t, allowed, identifier.ACMEIdentifiers{
+		identifier.NewDNS("www.example.com"),
+		identifier.NewIP(netip.MustParseAddr("9.9.9.9")),
+	})
+	test.AssertEquals(t, len(rejected), 3)
+	test.AssertEquals(t, rejected[0].Identifier, identifier.NewDNS("www.example.org"))
+	test.AssertEquals(t, rejected[0].Type, berrors.RejectedIdentifier)
+	test.AssertEquals(t, rejected[1].Identifier, identifier.NewIP(netip.MustParseAddr("64.112.117.1")))
+	test.AssertEquals(t, rejected[1].Type, berrors.RejectedIdentifier)
+	test.AssertEquals(t, rejected[2].Identifier, identifier.NewDNS("bad..example.com"))
+	test.AssertEquals(t, rejected[2].Type, berrors.Malformed)
+
+	// All acceptable.
+	allowed, rejected = pa.PartitionIssuable(identifier.ACMEIdentifiers{identifier.NewDNS("www.example.com")})
+	test.AssertEquals(t, len(allowed), 1)
+	test.AssertEquals(t, len(rejected), 0)
+}
+
+func TestDryRunIdentPolicyFile(t *testing.T) {
+	t.Parallel()
+
+	writePolicy := func(t *testing.T, policy blockedIdentsPolicy) string {
+		t.Helper()
+		policyBytes, err := yaml.Marshal(policy)
+		test.AssertNotError(t, err, "Couldn't serialize policy")
+		path := filepath.Join(t.TempDir(), "policy.yaml")
+		err = os.WriteFile(path, policyBytes, 0640)
+		test.AssertNotError(t, err, "Couldn't write policy file")
+		return path
+	}
+
+	pa := paImpl(t)
+	err := pa.processIdentPolicy(blockedIdentsPolicy{
+		HighRiskBlockedNames: []string{"example.org"},
+		ExactBlockedNames:    []string{"highvalue.example.org"},
+	})
+	test.AssertNotError(t, err, "Couldn't load policy contents")
+
+	t.Run("valid", func(t *testing.T) {
+		path := writePolicy(t, blockedIdentsPolicy{
+			HighRiskBlockedNames: []string{"example.com", "example.net"},
+			ExactBlockedNames:    []string{"highvalue.example.com"},
+			AdminBlockedNames:    []string{"example.info"},
+			AdminBlockedPrefixes: []string{"64.112.117.0/24"},
+			RegexBlockedNames:    []string{`^www\d+\.example\.org$`},
+			AllowedNames:         []string{"example.com", "example.net"},
+		})
+		report, err := pa.DryRunIdentPolicyFile(path)
+		test.AssertNotError(t, err, "DryRunIdentPolicyFile failed")
+		test.AssertEquals(t, len(report.SHA256), 64)
+		test.AssertDeepEquals(t, report.Counts, map[string]int{
+			"HighRiskBlockedNames": 2,
+			"AdminBlockedNames":    1,
+			"ExactBlockedNames":    1,
+			"AdminBlockedPrefixes": 1,
+			"RegexBlockedNames":    1,
+			"AllowedNames":         2,
+		})
+		test.AssertEquals(t, len(report.MalformedEntries), 0)
+		test.AssertEquals(t, len(report.Warnings), 0)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		path := writePolicy(t, blockedIdentsPolicy{
+			HighRiskBlockedNames: []string{"example.com", "example.com"},
+			ExactBlockedNames:    []string{"localhost"},
+			AdminBlockedNames:    []string{"example.com"},
+			AdminBlockedPrefixes: []string{"64.112.117.1"},
+			RegexBlockedNames:    []string{`^(www`},
+		})
+		report, err := pa.DryRunIdentPolicyFile(path)
+		test.AssertNotError(t, err, "DryRunIdentPolicyFile failed")
+		test.AssertEquals(t, len(report.MalformedEntries), 3)
+		test.AssertContains(t, report.MalformedEntries[0], `"localhost"`)
+		test.AssertContains(t, report.MalformedEntries[1], `"64.112.117.1"`)
+		test.AssertContains(t, report.MalformedEntries[2], `"^(www"`)
+		test.AssertEquals(t, len(report.Warnings), 2)
+
+		// The same file can't be loaded.
+		other := paImpl(t)
+		test.AssertError(t, other.LoadIdentPolicyFile(path), "LoadIdentPolicyFile should have failed")
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		path := writePolicy(t, blockedIdentsPolicy{
+			AdminBlockedNames: []string{"example.info"},
+		})
+		report, err := pa.DryRunIdentPolicyFile(path)
+		test.AssertNotError(t, err, "DryRunIdentPolicyFile failed")
+		test.AssertDeepEquals(t, report.MalformedEntries, []string{
+			"no entries in HighRiskBlockedNames",
+			"no entries in ExactBlockedNames",
+		})
+
+		// The same file can't be loaded.
+		other := paImpl(t)
+		test.AssertError(t, other.LoadIdentPolicyFile(path), "LoadIdentPolicyFile should have failed")
+	})
+
+	t.Run("unparseable", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "policy.yaml")
+		err := os.WriteFile(path, []byte("NotAList: true\n"), 0640)
+		test.AssertNotError(t, err, "Couldn't write policy file")
+		_, err = pa.DryRunIdentPolicyFile(path)
+		test.AssertError(t, err, "DryRunIdentPolicyFile should have failed")
+	})
+
+	// The running policy is unchanged.
+	test.AssertErrorIs(t, pa.WillingToIssue(identifier.ACMEIdentifiers{identifier.NewDNS("www.example.org")}), berrors.RejectedIdentifier)
+	test.AssertNotError(t, pa.WillingToIssue(identifier.ACMEIdentifiers{identifier.NewDNS("www.example.com")}), "WillingToIssue should have succeeded")
+}
+
+func TestAuditCertificate(t *testing.T) {
+	t.Parallel()
+
+	pa := paImpl(t)
+	err := pa.processIdentPolicy(blockedIdentsPolicy{
+		HighRiskBlockedNames: []string{"example.org"},
+		ExactBlockedNames:    []string{"highvalue.example.com"},
+	})
+	test.AssertNotError(t, err, "Couldn't load policy contents")
+
+	clean := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "www.example.com"},
+		DNSNames:     []string{"example.com", "www.example.com"},
+		IPAddresses:  []net.IP{net.ParseIP("64.112.117.1")},
+	}
+	test.AssertNotError(t, pa.AuditCertificate(clean), "clean certificate should pass audit")
+
+	// A certificate containing names which have since been blocklisted.
+	blocked := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		DNSNames:     []string{"example.com", "www.example.org", "highvalue.example.com"},
+	}
+	err = pa.AuditCertificate(blocked)
+	test.AssertErrorIs(t, err, berrors.RejectedIdentifier)
+	berr, ok := errors.AsType[*berrors.BoulderError](err)
+	test.Assert(t, ok, "expected a *BoulderError")
+	test.AssertEquals(t, len(berr.SubErrors), 2)
+	var failed []string
+	for _, subErr := range berr.SubErrors {
+		failed = append(failed, subErr.Identifier.Value)
+	}
+	slices.Sort(failed)
+	test.AssertDeepEquals(t, failed, []string{"highvalue.example.com", "www.example.org"})
+
+	empty := &x509.Certificate{SerialNumber: big.NewInt(3)}
+	test.AssertErrorIs(t, pa.AuditCertificate(empty), berrors.Malformed)
+}
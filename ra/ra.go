@@ -1078,7 +1078,8 @@ func (ra *RegistrationAuthorityImpl) validateFinalizeRequest(
 	}
 
 	// Check that the order names and the CSR names are an exact match
-	if !slices.Equal(csrIdents, orderIdents) {
+	missing, extra := identifier.IdentifierSetDiff(orderIdents, csrIdents)
+	if len(missing) > 0 || len(extra) > 0 {
 		return nil, nil, berrors.UnauthorizedError("CSR does not specify same identifiers as Order")
 	}
 
@@ -2118,22 +2119,13 @@ func (ra *RegistrationAuthorityImpl) NewOrder(ctx context.Context, req *rapb.New
 			"Order cannot contain more than %d identifiers", profile.maxNames)
 	}
 
-	for _, ident := range idents {
-		if !slices.Contains(profile.identifierTypes, ident.Type) {
-			name := "Default profile"
-			if req.CertificateProfileName != "" {
-				name = fmt.Sprintf("Profile %q", req.CertificateProfileName)
-			}
-			identType := "unknown"
-			switch ident.Type {
-			case identifier.TypeIP:
-				identType = "IP address"
-			case identifier.TypeDNS:
-				identType = "DNS"
-			}
-			return nil, berrors.RejectedIdentifierError("%s does not permit %s identifiers. "+
-				"See available profiles at https://letsencrypt.org/docs/profiles/.", name, identType)
-		}
+	profileName := req.CertificateProfileName
+	if profileName == "" {
+		profileName = ra.profiles.defaultName
+	}
+	err = ra.PA.CheckProfileIdentifierTypes(profileName, profile.identifierTypes, idents)
+	if err != nil {
+		return nil, err
 	}
 
 	// Validate that our policy allows issuing for each of the identifiers in
@@ -2151,8 +2143,9 @@ func (ra *RegistrationAuthorityImpl) NewOrder(ctx context.Context, req *rapb.New
 	// See if there is an existing unexpired pending (or ready) order that can be reused
 	// for this account
 	existingOrder, err := ra.SA.GetOrderForNames(ctx, &sapb.GetOrderForNamesRequest{
-		AcctID:      req.RegistrationID,
-		Identifiers: idents.ToProtoSlice(),
+		AcctID:                 req.RegistrationID,
+		Identifiers:            idents.ToProtoSlice(),
+		CertificateProfileName: req.CertificateProfileName,
 	})
 	// If there was an error and it wasn't an acceptable "NotFound" error, return
 	// immediately
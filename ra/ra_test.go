@@ -358,7 +358,7 @@ func initAuthorities(t *testing.T) (*DummyValidationAuthority, sapb.StorageAutho
 	rlSource := ratelimits.NewInmemSource()
 	limiter, err := ratelimits.NewLimiter(fc, rlSource, stats)
 	test.AssertNotError(t, err, "making limiter")
-	txnBuilder, err := ratelimits.NewTransactionBuilderFromFiles("../test/config-next/ratelimit-defaults.yml", "", metrics.NoopRegisterer, log)
+	txnBuilder, err := ratelimits.NewTransactionBuilderFromFiles("../test/config-next/ratelimit-defaults.yml", false, ratelimits.RoundTruncate, "", false, metrics.NoopRegisterer, log)
 	test.AssertNotError(t, err, "making transaction composer")
 
 	testKeyPolicy, err := goodkey.NewPolicy(nil, nil)
@@ -628,7 +628,7 @@ func TestPerformValidation_FailedValidationsTriggerPauseIdentifiersRatelimit(t *
 			Burst:  1,
 			Count:  1,
 			Period: config.Duration{Duration: time.Hour * 24}},
-	}, nil, metrics.NoopRegisterer, blog.NewMock())
+	}, ratelimits.RoundTruncate, nil, metrics.NoopRegisterer, blog.NewMock())
 	test.AssertNotError(t, err, "making transaction composer")
 	ra.txnBuilder = txnBuilder
 
@@ -891,7 +891,7 @@ func TestDeactivateAuthorization_Pausing(t *testing.T) {
 			Burst:  1,
 			Count:  1,
 			Period: config.Duration{Duration: time.Hour * 24}},
-	}, nil, metrics.NoopRegisterer, blog.NewMock())
+	}, ratelimits.RoundTruncate, nil, metrics.NoopRegisterer, blog.NewMock())
 	test.AssertNotError(t, err, "making transaction composer")
 	ra.txnBuilder = txnBuilder
 
@@ -1837,13 +1837,14 @@ func TestNewOrder_ProfileIdentifierTypes(t *testing.T) {
 		identTypes []identifier.IdentifierType
 		idents     []*corepb.Identifier
 		expectErr  string
+		expectSubs int
 	}{
 		{
 			name:       "Default profile bans IPs",
 			profile:    "",
 			identTypes: []identifier.IdentifierType{identifier.TypeDNS},
 			idents:     []*corepb.Identifier{identifier.NewIP(randomIPv6()).ToProto()},
-			expectErr:  "Default profile does not permit IP address identifiers",
+			expectErr:  "Profile \"test\" does not permit IP address identifiers",
 		},
 		{
 			name:       "Permit DNS, provide DNS names",
@@ -1891,6 +1892,14 @@ func TestNewOrder_ProfileIdentifierTypes(t *testing.T) {
 			idents:     []*corepb.Identifier{identifier.NewIP(randomIPv6()).ToProto(), identifier.NewDNS(randomDomain()).ToProto()},
 			expectErr:  "Profile \"test\" does not permit DNS identifiers",
 		},
+		{
+			name:       "Permit DNS, provide two IPs",
+			profile:    "test",
+			identTypes: []identifier.IdentifierType{identifier.TypeDNS},
+			idents:     []*corepb.Identifier{identifier.NewIP(randomIPv6()).ToProto(), identifier.NewIP(randomIPv6()).ToProto()},
+			expectErr:  "Profile \"test\" does not permit IP address identifiers",
+			expectSubs: 2,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -1912,6 +1921,12 @@ func TestNewOrder_ProfileIdentifierTypes(t *testing.T) {
 			if tc.expectErr != "" {
 				test.AssertErrorIs(t, err, berrors.RejectedIdentifier)
 				test.AssertContains(t, err.Error(), tc.expectErr)
+				bErr, ok := errors.AsType[*berrors.BoulderError](err)
+				test.Assert(t, ok, "error should be a BoulderError")
+				test.AssertEquals(t, len(bErr.SubErrors), tc.expectSubs)
+				for _, subErr := range bErr.SubErrors {
+					test.AssertEquals(t, subErr.Type, berrors.RejectedIdentifier)
+				}
 			} else {
 				test.AssertNotError(t, err, "NewOrder failed")
 			}
@@ -1919,6 +1934,36 @@ func TestNewOrder_ProfileIdentifierTypes(t *testing.T) {
 	}
 }
 
+func TestNewOrder_MaxLabels(t *testing.T) {
+	_, _, ra, _, _, registration, cleanUp := initAuthorities(t)
+	defer cleanUp()
+
+	// 11 labels, one more than the default limit.
+	name := "a.b.c.d.e.f.g.h." + randomDomain()
+	orderReq := &rapb.NewOrderRequest{
+		RegistrationID: registration.Id,
+		Identifiers:    []*corepb.Identifier{identifier.NewDNS(name).ToProto()},
+	}
+
+	_, err := ra.NewOrder(context.Background(), orderReq)
+	test.AssertErrorIs(t, err, berrors.RejectedIdentifier)
+	test.AssertContains(t, err.Error(), "more than 10 labels")
+
+	pa, err := policy.New(
+		map[identifier.IdentifierType]bool{identifier.TypeDNS: true},
+		map[core.AcmeChallenge]bool{core.ChallengeTypeHTTP01: true},
+		blog.NewMock(),
+		policy.WithMaxLabels(12))
+	test.AssertNotError(t, err, "Couldn't create PA")
+	err = pa.LoadIdentPolicyFile("../test/ident-policy.yaml")
+	test.AssertNotError(t, err, "Couldn't set identifier policy")
+	ra.PA = pa
+
+	order, err := ra.NewOrder(context.Background(), orderReq)
+	test.AssertNotError(t, err, "NewOrder should accept 11 labels with a limit of 12")
+	test.AssertEquals(t, order.Identifiers[0].Value, name)
+}
+
 // mockSAWithAuthzs has a GetValidAuthorizations2 method that returns the protobuf
 // version of its authzs struct member. It also has a fake GetOrderForNames
 // which always fails, and a fake NewOrderAndAuthzs which always succeeds, to
@@ -2698,6 +2743,48 @@ func TestFinalizeOrderWithMixedSANAndCN(t *testing.T) {
 	test.AssertEquals(t, updatedOrder.Status, "valid")
 }
 
+func TestFinalizeOrderCAError(t *testing.T) {
+	_, sa, ra, _, _, registration, cleanUp := initAuthorities(t)
+	defer cleanUp()
+
+	exp := ra.clk.Now().Add(365 * 24 * time.Hour)
+	authzID := createFinalizedAuthorization(t, sa, registration.Id, identifier.NewDNS("not-example.com"), exp, core.ChallengeTypeHTTP01, ra.clk.Now())
+
+	order, err := sa.NewOrderAndAuthzs(context.Background(), &sapb.NewOrderAndAuthzsRequest{
+		NewOrder: &sapb.NewOrderRequest{
+			RegistrationID: registration.Id,
+			Expires:        timestamppb.New(exp),
+			Identifiers: []*corepb.Identifier{
+				identifier.NewDNS("not-example.com").ToProto(),
+			},
+			V2Authorizations: []int64{authzID},
+		},
+	})
+	test.AssertNotError(t, err, "Could not add test order with finalized authz ID")
+
+	testKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "error generating test key")
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		PublicKey:          testKey.PublicKey,
+		SignatureAlgorithm: x509.SHA256WithRSA,
+		DNSNames:           []string{"not-example.com"},
+	}, testKey)
+	test.AssertNotError(t, err, "Could not create CSR")
+
+	caErr := berrors.InternalServerError("HSM is on fire")
+	ra.CA = &mocks.MockCA{IssueError: caErr}
+
+	_, err = ra.FinalizeOrder(context.Background(), &rapb.FinalizeOrderRequest{Order: order, Csr: csr})
+	test.AssertError(t, err, "FinalizeOrder should fail when the CA fails")
+	test.AssertErrorIs(t, err, caErr)
+
+	// The order should have been failed.
+	updatedOrder, err := sa.GetOrder(context.Background(), &sapb.OrderRequest{Id: order.Id})
+	test.AssertNotError(t, err, "Error getting order to check status")
+	test.AssertEquals(t, updatedOrder.Status, string(core.StatusInvalid))
+	test.AssertEquals(t, updatedOrder.CertificateSerial, "")
+}
+
 func TestFinalizeOrderWildcard(t *testing.T) {
 	_, sa, ra, _, _, registration, cleanUp := initAuthorities(t)
 	defer cleanUp()
@@ -59,6 +59,21 @@ func maybeSpend(clk clock.Clock, txn Transaction, tat time.Time) *Decision {
 	}
 }
 
+// PreviewRetryAfter returns how long, as of now, a request with a cost of 1
+// would have to wait before being allowed by this limit, given the bucket's
+// current TAT. It returns 0 if the request would be allowed immediately. Unlike
+// maybeSpend, it computes no new TAT and so never changes the bucket's state.
+func (l *Limit) PreviewRetryAfter(tat time.Time, now time.Time) time.Duration {
+	nowUnix := now.UnixNano()
+	tatUnix := max(nowUnix, tat.UnixNano())
+
+	difference := nowUnix - (tatUnix + l.emissionInterval - l.burstOffset)
+	if difference < 0 {
+		return -time.Duration(difference)
+	}
+	return 0
+}
+
 // maybeRefund uses the Generic Cell Rate Algorithm (GCRA) to attempt to refund
 // the cost of a request which was previously spent. The refund cost must be 0
 // or greater. A cost will only be refunded up to the burst capacity of the
@@ -13,7 +13,7 @@ import (
 func TestDecide(t *testing.T) {
 	clk := clock.NewFake()
 	limit := &Limit{Burst: 10, Count: 1, Period: config.Duration{Duration: time.Second}}
-	limit.precompute()
+	limit.precompute(RoundTruncate)
 
 	// Begin by using 1 of our 10 requests.
 	d := maybeSpend(clk, Transaction{"test", limit, 1, true, true, false}, clk.Now())
@@ -140,7 +140,7 @@ func TestDecide(t *testing.T) {
 func TestMaybeRefund(t *testing.T) {
 	clk := clock.NewFake()
 	limit := &Limit{Burst: 10, Count: 1, Period: config.Duration{Duration: time.Second}}
-	limit.precompute()
+	limit.precompute(RoundTruncate)
 
 	// Begin by using 1 of our 10 requests.
 	d := maybeSpend(clk, Transaction{"test", limit, 1, true, true, false}, clk.Now())
@@ -234,3 +234,29 @@ func TestMaybeRefund(t *testing.T) {
 	test.AssertEquals(t, d.retryIn, time.Duration(0))
 	test.AssertEquals(t, d.resetIn, time.Duration(0))
 }
+
+func TestPreviewRetryAfter(t *testing.T) {
+	clk := clock.NewFake()
+	limit := &Limit{Burst: 10, Count: 1, Period: config.Duration{Duration: time.Second}}
+	limit.precompute(RoundTruncate)
+
+	// An empty bucket admits a request immediately.
+	test.AssertEquals(t, limit.PreviewRetryAfter(clk.Now(), clk.Now()), time.Duration(0))
+	test.AssertEquals(t, limit.PreviewRetryAfter(clk.Now().Add(-time.Hour), clk.Now()), time.Duration(0))
+
+	// Fill the bucket, then preview the next request.
+	d := maybeSpend(clk, Transaction{"test", limit, 10, true, true, false}, clk.Now())
+	test.Assert(t, d.allowed, "should be allowed")
+	retryAfter := limit.PreviewRetryAfter(d.newTAT, clk.Now())
+	test.AssertEquals(t, retryAfter, time.Second)
+
+	// The preview agrees with maybeSpend, and doesn't change the TAT.
+	denied := maybeSpend(clk, Transaction{"test", limit, 1, true, true, false}, d.newTAT)
+	test.Assert(t, !denied.allowed, "should not be allowed")
+	test.AssertEquals(t, denied.retryIn, retryAfter)
+	test.AssertEquals(t, limit.PreviewRetryAfter(d.newTAT, clk.Now()), retryAfter)
+
+	// After waiting that long, the request is admitted.
+	clk.Add(retryAfter)
+	test.AssertEquals(t, limit.PreviewRetryAfter(d.newTAT, clk.Now()), time.Duration(0))
+}
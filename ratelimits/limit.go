@@ -5,8 +5,10 @@ import (
 	"encoding/csv"
 	"errors"
 	"fmt"
+	"io"
 	"net/netip"
 	"os"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -90,9 +92,38 @@ type Limit struct {
 	isOverride bool
 }
 
-// precompute calculates the emissionInterval and burstOffset for the limit.
-func (l *Limit) precompute() {
-	l.emissionInterval = l.Period.Nanoseconds() / l.Count
+// RoundingMode determines how a limit's emission interval (period / count) is
+// rounded to a whole number of nanoseconds when the period is not evenly
+// divisible by the count.
+type RoundingMode string
+
+const (
+	// RoundTruncate rounds the emission interval toward zero. The resulting
+	// interval may be up to 1ns shorter than exact, so the effective rate can
+	// very slightly exceed the configured count per period. This is the
+	// default, and the zero value behaves the same way.
+	RoundTruncate RoundingMode = "truncate"
+
+	// RoundCeil rounds the emission interval up. The resulting interval may be
+	// up to 1ns longer than exact, so the effective rate never exceeds the
+	// configured count per period, at the cost of being very slightly stricter.
+	RoundCeil RoundingMode = "ceil"
+)
+
+// emissionInterval returns period divided by count, in nanoseconds, rounded
+// according to mode.
+func emissionInterval(period time.Duration, count int64, mode RoundingMode) int64 {
+	interval := period.Nanoseconds() / count
+	if mode == RoundCeil && period.Nanoseconds()%count != 0 {
+		interval++
+	}
+	return interval
+}
+
+// precompute calculates the emissionInterval and burstOffset for the limit,
+// rounding the emissionInterval according to the provided mode.
+func (l *Limit) precompute(rounding RoundingMode) {
+	l.emissionInterval = emissionInterval(l.Period.Duration, l.Count, rounding)
 	l.burstOffset = l.emissionInterval * l.Burst
 }
 
@@ -111,14 +142,30 @@ func ValidateLimit(l *Limit) error {
 
 type Limits map[string]*Limit
 
+// envReferenceRegexp matches a ${VAR} reference to an environment variable.
+var envReferenceRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnvReferences replaces each ${VAR} reference in data with the value of
+// the corresponding environment variable, or the empty string if it is unset.
+// Unlike os.ExpandEnv, any other '$', including a bare $VAR, is left untouched.
+func expandEnvReferences(data []byte) []byte {
+	return envReferenceRegexp.ReplaceAllFunc(data, func(ref []byte) []byte {
+		return []byte(os.Getenv(string(envReferenceRegexp.FindSubmatch(ref)[1])))
+	})
+}
+
 // loadDefaultsFromFile unmarshals the defaults YAML file at path into a map of
-// limits.
-func loadDefaultsFromFile(path string) (LimitConfigs, error) {
+// limits. If expandEnv is true, ${VAR} references in the file are first
+// replaced with the values of the corresponding environment variables.
+func loadDefaultsFromFile(path string, expandEnv bool) (LimitConfigs, error) {
 	lm := make(LimitConfigs)
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
+	if expandEnv {
+		data = expandEnvReferences(data)
+	}
 	err = strictyaml.Unmarshal(data, &lm)
 	if err != nil {
 		return nil, err
@@ -210,7 +257,12 @@ func parseOverrideNameEnumId(key string) (Name, string, error) {
 // formatted as a list of maps, where each map has a single key representing the
 // limit name and a value that is a map containing the limit fields and an
 // additional 'ids' field that is a list of ids that this override applies to.
-func parseOverrideLimits(newOverridesYAML overridesYAML) (Limits, error) {
+//
+// Two entries which resolve to the same bucket key are rejected with an error
+// naming the duplicated key, unless allowDuplicates is true, in which case the
+// last entry wins. Last-wins is retained only for backward compatibility with
+// existing overrides files.
+func parseOverrideLimits(newOverridesYAML overridesYAML, allowDuplicates bool) (Limits, error) {
 	parsed := make(Limits)
 
 	for _, ov := range newOverridesYAML {
@@ -242,7 +294,15 @@ func parseOverrideLimits(newOverridesYAML overridesYAML) (Limits, error) {
 						"validating name %s and id %q for override limit %q: %w", name, id, k, err)
 				}
 
-				parsed[joinWithColon(name.EnumString(), id)] = lim
+				bucketKey := joinWithColon(name.EnumString(), id)
+				_, ok := parsed[bucketKey]
+				if ok && !allowDuplicates {
+					// Different ids can compute the same bucket key, e.g. the
+					// same FQDN set listed in a different order.
+					return nil, fmt.Errorf(
+						"duplicate bucket key %q for id %q in override limit %q", bucketKey, entry.Id, k)
+				}
+				parsed[bucketKey] = lim
 			}
 		}
 	}
@@ -265,6 +325,14 @@ func hydrateOverrideLimit(bucketKey string, limitName Name) (string, error) {
 	// since their keys aren't nice to store in a config file or database entry.
 	switch limitName {
 	case CertificatesPerDomain:
+		// Clear any host bits from prefixes in CIDR notation. Prefixes larger
+		// than the covering prefix are kept as-is, and apply to every covering
+		// prefix they contain.
+		prefix, err := netip.ParsePrefix(bucketKey)
+		if err == nil {
+			bucketKey = prefix.Masked().String()
+			break
+		}
 		// Convert IP addresses to their covering /32 (IPv4) or /64
 		// (IPv6) prefixes in CIDR notation.
 		ip, err := netip.ParseAddr(bucketKey)
@@ -277,14 +345,24 @@ func hydrateOverrideLimit(bucketKey string, limitName Name) (string, error) {
 		}
 	case CertificatesPerFQDNSet:
 		// Compute the hash of a comma-separated list of identifier values.
-		bucketKey = fmt.Sprintf("%x", core.HashIdentifiers(identifier.FromStringSlice(strings.Split(bucketKey, ","))))
+		bucketKey = FQDNSetOverrideID(identifier.FromStringSlice(strings.Split(bucketKey, ",")))
 	}
 
 	return bucketKey, nil
 }
 
+// CanonicalizeOverrideID validates the override id for the limit with the
+// provided name and returns the bucket key it is stored under in-memory. IP
+// addresses are converted to their covering prefixes and FQDN sets to their
+// hash, exactly as they are when overrides are loaded from a file, so that
+// tooling which migrates overrides to the database produces identical keys.
+func CanonicalizeOverrideID(name Name, id string) (string, error) {
+	return hydrateOverrideLimit(id, name)
+}
+
 // parseDefaultLimits validates a map of default limits and rekeys it by 'Name'.
-func parseDefaultLimits(newDefaultLimits LimitConfigs) (Limits, error) {
+// Emission intervals are rounded according to the provided mode.
+func parseDefaultLimits(newDefaultLimits LimitConfigs, rounding RoundingMode) (Limits, error) {
 	parsed := make(Limits)
 
 	for k, v := range newDefaultLimits {
@@ -305,7 +383,7 @@ func parseDefaultLimits(newDefaultLimits LimitConfigs) (Limits, error) {
 			return nil, fmt.Errorf("parsing default limit %q: %w", k, err)
 		}
 
-		lim.precompute()
+		lim.precompute(rounding)
 		parsed[name.EnumString()] = lim
 	}
 	return parsed, nil
@@ -333,10 +411,19 @@ type limitRegistry struct {
 	// refreshOverrides is a function to refresh override limits.
 	refreshOverrides OverridesRefresher
 
+	// rounding is the RoundingMode used when precomputing the emission
+	// interval of each override as it is loaded.
+	rounding RoundingMode
+
 	overridesTimestamp prometheus.Gauge
 	overridesErrors    prometheus.Gauge
 	overridesPerLimit  prometheus.GaugeVec
 
+	// lookups counts calls to getLimit, partitioned by limit name and by
+	// whether an override, a default, or neither (the limit is disabled) was
+	// found. If nil, lookups are not counted.
+	lookups *prometheus.CounterVec
+
 	logger blog.Logger
 }
 
@@ -356,16 +443,60 @@ func (l *limitRegistry) getLimit(name Name, bucketKey string) (*Limit, error) {
 		// Check for override.
 		ol, ok := l.overrides[bucketKey]
 		if ok {
+			l.countLookup(name, "override")
 			return ol, nil
 		}
+		if name == CertificatesPerDomain {
+			ol, ok = l.broaderPrefixOverride(name, bucketKey)
+			if ok {
+				l.countLookup(name, "override")
+				return ol, nil
+			}
+		}
 	}
 	dl, ok := l.defaults[name.EnumString()]
 	if ok {
+		l.countLookup(name, "default")
 		return dl, nil
 	}
+	l.countLookup(name, "disabled")
 	return nil, errLimitDisabled
 }
 
+// broaderPrefixOverride returns the override, if any, for the most specific
+// prefix which strictly contains the IP prefix in bucketKey. It returns false if
+// bucketKey's id is not an IP prefix. The caller must hold the read lock.
+func (l *limitRegistry) broaderPrefixOverride(name Name, bucketKey string) (*Limit, bool) {
+	_, id, found := strings.Cut(bucketKey, ":")
+	if !found {
+		return nil, false
+	}
+	prefix, err := netip.ParsePrefix(id)
+	if err != nil {
+		return nil, false
+	}
+	for bits := prefix.Bits() - 1; bits >= 0; bits-- {
+		broader, err := prefix.Addr().Prefix(bits)
+		if err != nil {
+			return nil, false
+		}
+		ol, ok := l.overrides[joinWithColon(name.EnumString(), broader.String())]
+		if ok {
+			return ol, true
+		}
+	}
+	return nil, false
+}
+
+// countLookup increments the lookups counter, if there is one, for the given
+// limit name and result.
+func (l *limitRegistry) countLookup(name Name, result string) {
+	if l.lookups == nil {
+		return
+	}
+	l.lookups.WithLabelValues(name.String(), result).Inc()
+}
+
 // loadOverrides replaces this registry's overrides with a new dataset.
 func (l *limitRegistry) loadOverrides(ctx context.Context) error {
 	newOverrides, err := l.refreshOverrides(ctx, l.overridesErrors, l.logger)
@@ -392,7 +523,7 @@ func (l *limitRegistry) loadOverrides(ctx context.Context) error {
 
 	newOverridesPerLimit := make(map[Name]float64)
 	for _, override := range newOverrides {
-		override.precompute()
+		override.precompute(l.rounding)
 		newOverridesPerLimit[override.Name]++
 	}
 
@@ -479,12 +610,23 @@ func LoadOverridesByBucketKey(path string) (Limits, error) {
 	if err != nil {
 		return nil, err
 	}
-	return parseOverrideLimits(ovs)
+	return parseOverrideLimits(ovs, false)
 }
 
-// DumpOverrides writes the provided overrides to CSV at the supplied path. Each
-// override is written as a single row, one per ID. Rows are sorted in the
-// following order:
+// DumpOverrides writes the provided overrides to CSV at the supplied path, as
+// described by DumpOverridesTo.
+func DumpOverrides(path string, overrides Limits) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return DumpOverridesTo(f, overrides)
+}
+
+// DumpOverridesTo writes the provided overrides to w as CSV. Each override is
+// written as a single row, one per ID. Rows are sorted in the following order:
 //   - Name    (ascending)
 //   - Count   (descending)
 //   - Burst   (descending)
@@ -494,7 +636,7 @@ func LoadOverridesByBucketKey(path string) (Limits, error) {
 //
 // This function supports admin tooling that routinely exports the overrides
 // table for investigation or auditing.
-func DumpOverrides(path string, overrides Limits) error {
+func DumpOverridesTo(w io.Writer, overrides Limits) error {
 	type row struct {
 		name    string
 		id      string
@@ -546,25 +688,19 @@ func DumpOverrides(path string, overrides Limits) error {
 		return rows[i].id < rows[j].id
 	})
 
-	f, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	w := csv.NewWriter(f)
-	err = w.Write([]string{"name", "id", "count", "burst", "period", "comment"})
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"name", "id", "count", "burst", "period", "comment"})
 	if err != nil {
 		return err
 	}
 
 	for _, r := range rows {
-		err := w.Write([]string{r.name, r.id, strconv.FormatInt(r.count, 10), strconv.FormatInt(r.burst, 10), r.period, r.comment})
+		err := cw.Write([]string{r.name, r.id, strconv.FormatInt(r.count, 10), strconv.FormatInt(r.burst, 10), r.period, r.comment})
 		if err != nil {
 			return err
 		}
 	}
-	w.Flush()
+	cw.Flush()
 
-	return w.Error()
+	return cw.Error()
 }
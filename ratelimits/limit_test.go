@@ -1,6 +1,7 @@
 package ratelimits
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -28,12 +29,12 @@ import (
 //
 // TODO(#7901): Update the tests to test these functions individually.
 func loadAndParseDefaultLimits(path string) (Limits, error) {
-	fromFile, err := loadDefaultsFromFile(path)
+	fromFile, err := loadDefaultsFromFile(path, false)
 	if err != nil {
 		return nil, err
 	}
 
-	return parseDefaultLimits(fromFile)
+	return parseDefaultLimits(fromFile, RoundTruncate)
 }
 
 // loadAndParseOverrideLimitsFromFile is a helper that calls both
@@ -46,7 +47,7 @@ func loadAndParseOverrideLimitsFromFile(path string) (Limits, error) {
 		return nil, err
 	}
 
-	return parseOverrideLimits(fromFile)
+	return parseOverrideLimits(fromFile, false)
 }
 
 func TestParseOverrideNameId(t *testing.T) {
@@ -158,6 +159,85 @@ func TestValidateLimit(t *testing.T) {
 	}
 }
 
+func TestEmissionIntervalRounding(t *testing.T) {
+	t.Parallel()
+
+	// 1s / 3 = 333333333.33...ns, which is not a whole number of nanoseconds.
+	newLimit := func() *Limit {
+		return &Limit{Burst: 3, Count: 3, Period: config.Duration{Duration: time.Second}}
+	}
+
+	l := newLimit()
+	l.precompute(RoundTruncate)
+	test.AssertEquals(t, l.emissionInterval, int64(333333333))
+	test.AssertEquals(t, l.burstOffset, int64(999999999))
+
+	// The zero value behaves like RoundTruncate.
+	l = newLimit()
+	l.precompute("")
+	test.AssertEquals(t, l.emissionInterval, int64(333333333))
+
+	l = newLimit()
+	l.precompute(RoundCeil)
+	test.AssertEquals(t, l.emissionInterval, int64(333333334))
+	test.AssertEquals(t, l.burstOffset, int64(1000000002))
+
+	// Evenly divisible periods are unaffected by the rounding mode.
+	l = &Limit{Burst: 4, Count: 4, Period: config.Duration{Duration: time.Second}}
+	l.precompute(RoundCeil)
+	test.AssertEquals(t, l.emissionInterval, int64(250000000))
+
+	// The mode passed to the TransactionBuilder applies to default limits.
+	tb, err := NewTransactionBuilder(LimitConfigs{
+		NewRegistrationsPerIPAddress.String(): &LimitConfig{
+			Burst:  3,
+			Count:  3,
+			Period: config.Duration{Duration: time.Second}},
+	}, RoundCeil, nil, metrics.NoopRegisterer, blog.NewMock())
+	test.AssertNotError(t, err, "creating TransactionBuilder")
+	l, err = tb.getLimit(NewRegistrationsPerIPAddress, "")
+	test.AssertNotError(t, err, "getting default limit")
+	test.AssertEquals(t, l.emissionInterval, int64(333333334))
+}
+
+func TestLoadDefaultsExpandEnv(t *testing.T) {
+	// Not parallel: modifies the process environment.
+	t.Setenv("BOULDER_TEST_BURST", "42")
+
+	// With expansion disabled, the reference is passed through literally and
+	// fails to parse as a number.
+	_, err := loadDefaultsFromFile("testdata/working_default_env.yml", false)
+	test.AssertError(t, err, "unexpanded variable should not parse")
+
+	l, err := loadDefaultsFromFile("testdata/working_default_env.yml", true)
+	test.AssertNotError(t, err, "expanded variable should parse")
+	test.AssertEquals(t, l["NewRegistrationsPerIPAddress"].Burst, int64(42))
+	test.AssertEquals(t, l["NewRegistrationsPerIPAddress"].Count, int64(20))
+}
+
+func TestExpandEnvReferences(t *testing.T) {
+	// Not parallel: modifies the process environment.
+	t.Setenv("BOULDER_TEST_VALUE", "expanded")
+
+	testCases := []struct {
+		in   string
+		want string
+	}{
+		{in: "${BOULDER_TEST_VALUE}", want: "expanded"},
+		{in: "a${BOULDER_TEST_VALUE}b", want: "aexpandedb"},
+		{in: "${BOULDER_TEST_UNSET}", want: ""},
+		// Only ${VAR} references are expanded.
+		{in: "$BOULDER_TEST_VALUE", want: "$BOULDER_TEST_VALUE"},
+		{in: "cost: $5", want: "cost: $5"},
+		{in: "$", want: "$"},
+		{in: "${}", want: "${}"},
+		{in: "${BOULDER TEST}", want: "${BOULDER TEST}"},
+	}
+	for _, tc := range testCases {
+		test.AssertEquals(t, string(expandEnvReferences([]byte(tc.in))), tc.want)
+	}
+}
+
 func TestLoadAndParseOverrideLimitsFromFile(t *testing.T) {
 	// Load a single valid override limit with Id formatted as 'enum:RegId'.
 	l, err := loadAndParseOverrideLimitsFromFile("testdata/working_override.yml")
@@ -215,6 +295,15 @@ func TestLoadAndParseOverrideLimitsFromFile(t *testing.T) {
 	test.AssertEquals(t, l[entryKey4].Count, int64(60))
 	test.AssertEquals(t, l[entryKey4].Period.Duration, time.Second*4)
 
+	// The id computed from live identifiers, in any order, matches the
+	// override loaded from the file.
+	liveKey := joinWithColon(CertificatesPerFQDNSet.EnumString(), FQDNSetOverrideID(identifier.ACMEIdentifiers{
+		identifier.NewDNS("example.net"),
+		identifier.NewDNS("example.com"),
+	}))
+	test.AssertEquals(t, liveKey, entryKey2)
+	test.AssertEquals(t, l[liveKey].Period.Duration, time.Second*2)
+
 	// Path is empty string.
 	_, err = loadAndParseOverrideLimitsFromFile("")
 	test.AssertError(t, err, "path is empty string")
@@ -254,23 +343,50 @@ func TestLoadAndParseOverrideLimitsFromFile(t *testing.T) {
 	_, err = loadAndParseOverrideLimitsFromFile("testdata/busted_overrides_third_entry_bad_id.yml")
 	test.AssertError(t, err, "multiple override limits, third entry has bad Id value")
 	test.Assert(t, !os.IsNotExist(err), "test file should exist")
+
+	// Multiple entries for the same FQDN set, listed in different orders.
+	_, err = loadAndParseOverrideLimitsFromFile("testdata/busted_overrides_duplicate_fqdnset.yml")
+	test.AssertError(t, err, "multiple override limits with the same bucket key")
+	test.AssertContains(t, err.Error(), fmt.Sprintf("duplicate bucket key %q", entryKey2))
+	test.AssertContains(t, err.Error(), `"example.net,example.com"`)
+}
+
+func TestParseOverrideLimitsDuplicates(t *testing.T) {
+	t.Parallel()
+
+	ov, err := loadOverridesFromFile("testdata/busted_overrides_duplicate_id.yml")
+	test.AssertNotError(t, err, "loading overrides from file")
+	bucketKey := joinWithColon(NewRegistrationsPerIPAddress.EnumString(), "64.112.117.1")
+
+	// Strict mode rejects the duplicate and names the bucket key.
+	_, err = parseOverrideLimits(ov, false)
+	test.AssertError(t, err, "duplicate override should be rejected in strict mode")
+	test.AssertContains(t, err.Error(), fmt.Sprintf("duplicate bucket key %q", bucketKey))
+
+	// Last-wins mode keeps the final entry.
+	parsed, err := parseOverrideLimits(ov, true)
+	test.AssertNotError(t, err, "duplicate override should be allowed in last-wins mode")
+	test.AssertEquals(t, len(parsed), 1)
+	test.AssertEquals(t, parsed[bucketKey].Burst, int64(50))
+	test.AssertEquals(t, parsed[bucketKey].Period.Duration, 2*time.Second)
+	test.AssertEquals(t, parsed[bucketKey].Comment, "Bar")
 }
 
 func TestLoadOverrides(t *testing.T) {
 	mockLog := blog.NewMock()
 
-	tb, err := NewTransactionBuilderFromFiles("../test/config-next/ratelimit-defaults.yml", "../test/config-next/ratelimit-overrides.yml", metrics.NoopRegisterer, mockLog)
+	tb, err := NewTransactionBuilderFromFiles("../test/config-next/ratelimit-defaults.yml", false, RoundTruncate, "../test/config-next/ratelimit-overrides.yml", false, metrics.NoopRegisterer, mockLog)
 	test.AssertNotError(t, err, "creating TransactionBuilder")
 	err = tb.loadOverrides(context.Background())
 	test.AssertNotError(t, err, "loading overrides in TransactionBuilder")
 	overridesData, err := loadOverridesFromFile("../test/config-next/ratelimit-overrides.yml")
 	test.AssertNotError(t, err, "loading overrides from file")
-	testOverrides, err := parseOverrideLimits(overridesData)
+	testOverrides, err := parseOverrideLimits(overridesData, false)
 	test.AssertNotError(t, err, "parsing overrides")
 
 	newOverridesPerLimit := make(map[Name]float64)
 	for _, override := range testOverrides {
-		override.precompute()
+		override.precompute(RoundTruncate)
 		newOverridesPerLimit[override.Name]++
 	}
 
@@ -310,6 +426,42 @@ func TestLoadOverrides(t *testing.T) {
 	test.AssertDeepEquals(t, tb.limitRegistry.overrides, testOverrides)
 }
 
+func TestGetLimitLookups(t *testing.T) {
+	t.Parallel()
+
+	tb, err := NewTransactionBuilder(LimitConfigs{
+		NewRegistrationsPerIPAddress.String(): &LimitConfig{
+			Burst:  10,
+			Count:  10,
+			Period: config.Duration{Duration: time.Hour}},
+	}, RoundTruncate, nil, prometheus.NewRegistry(), blog.NewMock())
+	test.AssertNotError(t, err, "creating TransactionBuilder")
+
+	overriddenKey := newIPAddressBucketKey(NewRegistrationsPerIPAddress, netip.MustParseAddr("10.0.0.1"))
+	tb.limitRegistry.overrides = Limits{
+		overriddenKey: {Name: NewRegistrationsPerIPAddress, Burst: 20, Count: 20, Period: config.Duration{Duration: time.Hour}},
+	}
+
+	_, err = tb.getLimit(NewRegistrationsPerIPAddress, overriddenKey)
+	test.AssertNotError(t, err, "getting overridden limit")
+	_, err = tb.getLimit(NewRegistrationsPerIPAddress, newIPAddressBucketKey(NewRegistrationsPerIPAddress, netip.MustParseAddr("10.0.0.2")))
+	test.AssertNotError(t, err, "getting default limit")
+	_, err = tb.getLimit(NewRegistrationsPerIPAddress, "")
+	test.AssertNotError(t, err, "getting default limit")
+	_, err = tb.getLimit(NewOrdersPerAccount, "")
+	test.AssertErrorIs(t, err, errLimitDisabled)
+
+	lookups := tb.limitRegistry.lookups
+	test.AssertMetricWithLabelsEquals(t, lookups, prometheus.Labels{"name": NewRegistrationsPerIPAddress.String(), "result": "override"}, 1)
+	test.AssertMetricWithLabelsEquals(t, lookups, prometheus.Labels{"name": NewRegistrationsPerIPAddress.String(), "result": "default"}, 2)
+	test.AssertMetricWithLabelsEquals(t, lookups, prometheus.Labels{"name": NewOrdersPerAccount.String(), "result": "disabled"}, 1)
+
+	// A registry without a lookups counter should still work.
+	reg := &limitRegistry{defaults: tb.limitRegistry.defaults}
+	_, err = reg.getLimit(NewRegistrationsPerIPAddress, "")
+	test.AssertNotError(t, err, "getting default limit without a lookups counter")
+}
+
 func TestNewRefresher(t *testing.T) {
 	mockLog := blog.NewMock()
 
@@ -394,6 +546,42 @@ func TestHydrateOverrideLimit(t *testing.T) {
 			expectBucketKey: "2602:80a:6000:666::/64",
 			expectError:     "",
 		},
+		{
+			name:      "CertificatesPerDomain with IPv6 prefix",
+			bucketKey: "2602:80a:6000:666::/64",
+			limit: Limit{
+				Name:   StringToName["CertificatesPerDomain"],
+				Burst:  1,
+				Count:  1,
+				Period: config.Duration{Duration: time.Second},
+			},
+			expectBucketKey: "2602:80a:6000:666::/64",
+			expectError:     "",
+		},
+		{
+			name:      "CertificatesPerDomain with IPv6 prefix with host bits, should be normalized",
+			bucketKey: "2602:80a:6000:666::1/64",
+			limit: Limit{
+				Name:   StringToName["CertificatesPerDomain"],
+				Burst:  1,
+				Count:  1,
+				Period: config.Duration{Duration: time.Second},
+			},
+			expectBucketKey: "2602:80a:6000:666::/64",
+			expectError:     "",
+		},
+		{
+			name:      "CertificatesPerDomain with garbage prefix",
+			bucketKey: "2602:80a:6000:666::/garbage",
+			limit: Limit{
+				Name:   StringToName["CertificatesPerDomain"],
+				Burst:  1,
+				Count:  1,
+				Period: config.Duration{Duration: time.Second},
+			},
+			expectBucketKey: "",
+			expectError:     "invalid CIDR",
+		},
 		{
 			name:      "CertificatesPerFQDNSet",
 			bucketKey: "example.com,example.net,example.org",
@@ -424,6 +612,98 @@ func TestHydrateOverrideLimit(t *testing.T) {
 	}
 }
 
+func TestCanonicalizeOverrideID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name Name
+		id   string
+	}{
+		{CertificatesPerDomain, "example.com"},
+		{CertificatesPerDomain, "64.112.117.1"},
+		{CertificatesPerDomain, "2602:80a:6000:666::"},
+		{CertificatesPerDomain, "2602:80a:6000:777::1/64"},
+		{CertificatesPerFQDNSet, "example.com,example.net,64.112.117.1"},
+		{NewRegistrationsPerIPAddress, "64.112.117.1"},
+		{NewOrdersPerAccount, "13371338"},
+	}
+
+	var ov overridesYAML
+	for _, tc := range tests {
+		entry := overrideYAML{
+			LimitConfig: LimitConfig{Burst: 1, Count: 1, Period: config.Duration{Duration: time.Second}},
+		}
+		entry.Ids = append(entry.Ids, struct {
+			Id      string `yaml:"id"`
+			Comment string `yaml:"comment,omitempty"`
+		}{Id: tc.id})
+		ov = append(ov, map[string]overrideYAML{tc.name.String(): entry})
+	}
+	parsed, err := parseOverrideLimits(ov, false)
+	test.AssertNotError(t, err, "parsing overrides")
+
+	for _, tc := range tests {
+		id, err := CanonicalizeOverrideID(tc.name, tc.id)
+		test.AssertNotError(t, err, fmt.Sprintf("canonicalizing %s id %q", tc.name, tc.id))
+		_, ok := parsed[joinWithColon(tc.name.EnumString(), id)]
+		test.Assert(t, ok, fmt.Sprintf("canonical id %q for %s not found in parsed overrides", id, tc.name))
+	}
+
+	_, err = CanonicalizeOverrideID(CertificatesPerDomain, "VelociousVacherin")
+	test.AssertError(t, err, "expected error for invalid id")
+}
+
+func TestCertificatesPerDomainPrefixOverrides(t *testing.T) {
+	t.Parallel()
+
+	var ov overridesYAML
+	for i, id := range []string{"64.112.117.0/24", "64.112.118.1", "2602:80a:6000::/40"} {
+		entry := overrideYAML{
+			LimitConfig: LimitConfig{Burst: int64(i + 1), Count: int64(i + 1), Period: config.Duration{Duration: time.Second}},
+		}
+		entry.Ids = append(entry.Ids, struct {
+			Id      string `yaml:"id"`
+			Comment string `yaml:"comment,omitempty"`
+		}{Id: id})
+		ov = append(ov, map[string]overrideYAML{CertificatesPerDomain.String(): entry})
+	}
+	parsed, err := parseOverrideLimits(ov, false)
+	test.AssertNotError(t, err, "parsing overrides")
+
+	// A prefix is stored as-is, while a bare IP is converted to its covering
+	// prefix.
+	_, ok := parsed[joinWithColon(CertificatesPerDomain.EnumString(), "64.112.117.0/24")]
+	test.Assert(t, ok, "/24 override should be stored as-is")
+	_, ok = parsed[joinWithColon(CertificatesPerDomain.EnumString(), "64.112.118.1/32")]
+	test.Assert(t, ok, "bare IPv4 override should be stored as its /32")
+
+	// A prefix more specific than the covering prefix is rejected.
+	_, err = hydrateOverrideLimit("2602:80a:6000::/80", CertificatesPerDomain)
+	test.AssertError(t, err, "/80 override should be rejected")
+
+	reg := &limitRegistry{overrides: parsed}
+	keyFor := func(ip string) string {
+		prefix, err := coveringIPPrefix(CertificatesPerDomain, netip.MustParseAddr(ip))
+		test.AssertNotError(t, err, "computing covering prefix")
+		return newDomainOrCIDRBucketKey(CertificatesPerDomain, prefix.String())
+	}
+
+	// Covering prefixes within an override's prefix get its limit.
+	lim, err := reg.getLimit(CertificatesPerDomain, keyFor("64.112.117.200"))
+	test.AssertNotError(t, err, "getting limit within /24 override")
+	test.AssertEquals(t, lim.Burst, int64(1))
+	lim, err = reg.getLimit(CertificatesPerDomain, keyFor("64.112.118.1"))
+	test.AssertNotError(t, err, "getting limit for /32 override")
+	test.AssertEquals(t, lim.Burst, int64(2))
+	lim, err = reg.getLimit(CertificatesPerDomain, keyFor("2602:80a:6012:3456::1"))
+	test.AssertNotError(t, err, "getting limit within /40 override")
+	test.AssertEquals(t, lim.Burst, int64(3))
+
+	// Those outside of it don't.
+	_, err = reg.getLimit(CertificatesPerDomain, keyFor("64.112.119.1"))
+	test.AssertErrorIs(t, err, errLimitDisabled)
+}
+
 func TestLoadAndParseDefaultLimits(t *testing.T) {
 	// Load a single valid default limit.
 	l, err := loadAndParseDefaultLimits("testdata/working_default.yml")
@@ -473,6 +753,43 @@ func TestLoadAndParseDefaultLimits(t *testing.T) {
 	test.Assert(t, !os.IsNotExist(err), "test file should exist")
 }
 
+func TestDumpOverridesTo(t *testing.T) {
+	t.Parallel()
+
+	overrides := Limits{
+		joinWithColon(NewOrdersPerAccount.EnumString(), "12345"): {
+			Name:    NewOrdersPerAccount,
+			Count:   100,
+			Burst:   100,
+			Period:  config.Duration{Duration: time.Hour},
+			Comment: "Acme Corp",
+		},
+		joinWithColon(NewOrdersPerAccount.EnumString(), "67890"): {
+			Name:    NewOrdersPerAccount,
+			Count:   500,
+			Burst:   500,
+			Period:  config.Duration{Duration: time.Hour},
+			Comment: "Globex",
+		},
+		joinWithColon(NewRegistrationsPerIPAddress.EnumString(), "10.0.0.1"): {
+			Name:    NewRegistrationsPerIPAddress,
+			Count:   20,
+			Burst:   40,
+			Period:  config.Duration{Duration: 3 * time.Hour},
+			Comment: "Initech, Inc.",
+		},
+	}
+
+	var buf bytes.Buffer
+	err := DumpOverridesTo(&buf, overrides)
+	test.AssertNotError(t, err, "dumping overrides")
+	test.AssertEquals(t, buf.String(), `name,id,count,burst,period,comment
+NewOrdersPerAccount,67890,500,500,1h0m0s,Globex
+NewOrdersPerAccount,12345,100,100,1h0m0s,Acme Corp
+NewRegistrationsPerIPAddress,10.0.0.1,20,40,3h0m0s,"Initech, Inc."
+`)
+}
+
 func TestLoadAndDumpOverrides(t *testing.T) {
 	t.Parallel()
 
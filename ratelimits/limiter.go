@@ -107,6 +107,7 @@ func (d *Decision) Result(now time.Time) error {
 	switch d.transaction.limit.Name {
 	case NewRegistrationsPerIPAddress:
 		return berrors.RegistrationsPerIPAddressError(
+			d.transaction.limit.Name.String(),
 			retryAfter,
 			"too many new registrations (%d) from this IP address in the last %s, retry after %s",
 			d.transaction.limit.Burst,
@@ -116,6 +117,7 @@ func (d *Decision) Result(now time.Time) error {
 
 	case NewRegistrationsPerIPv6Range:
 		return berrors.RegistrationsPerIPv6RangeError(
+			d.transaction.limit.Name.String(),
 			retryAfter,
 			"too many new registrations (%d) from this /48 subnet of IPv6 addresses in the last %s, retry after %s",
 			d.transaction.limit.Burst,
@@ -124,6 +126,7 @@ func (d *Decision) Result(now time.Time) error {
 		)
 	case NewOrdersPerAccount:
 		return berrors.NewOrdersPerAccountError(
+			d.transaction.limit.Name.String(),
 			retryAfter,
 			"too many new orders (%d) from this account in the last %s, retry after %s",
 			d.transaction.limit.Burst,
@@ -139,6 +142,7 @@ func (d *Decision) Result(now time.Time) error {
 		}
 		identValue := d.transaction.bucketKey[idx+1:]
 		return berrors.FailedAuthorizationsPerDomainPerAccountError(
+			d.transaction.limit.Name.String(),
 			retryAfter,
 			"too many failed authorizations (%d) for %q in the last %s, retry after %s",
 			d.transaction.limit.Burst,
@@ -155,6 +159,7 @@ func (d *Decision) Result(now time.Time) error {
 		}
 		domainOrCIDR := d.transaction.bucketKey[idx+1:]
 		return berrors.CertificatesPerDomainError(
+			d.transaction.limit.Name.String(),
 			retryAfter,
 			"too many certificates (%d) already issued for %q in the last %s, retry after %s",
 			d.transaction.limit.Burst,
@@ -165,6 +170,7 @@ func (d *Decision) Result(now time.Time) error {
 
 	case CertificatesPerFQDNSet:
 		return berrors.CertificatesPerFQDNSetError(
+			d.transaction.limit.Name.String(),
 			retryAfter,
 			"too many certificates (%d) already issued for this exact set of identifiers in the last %s, retry after %s",
 			d.transaction.limit.Burst,
@@ -174,6 +180,7 @@ func (d *Decision) Result(now time.Time) error {
 
 	case LimitOverrideRequestsPerIPAddress:
 		return berrors.LimitOverrideRequestsPerIPAddressError(
+			d.transaction.limit.Name.String(),
 			retryAfter,
 			"too many override request form submissions (%d) from this IP address in the last %s, retry after %s",
 			d.transaction.limit.Burst,
@@ -2,6 +2,7 @@ package ratelimits
 
 import (
 	"context"
+	"errors"
 	"math/rand/v2"
 	"net"
 	"net/netip"
@@ -33,7 +34,7 @@ func newTestLimiter(t *testing.T, s Source, clk clock.FakeClock) *Limiter {
 //   - 'NewRegistrationsPerIPAddress' burst: 20 count: 20 period: 1s
 //   - 'NewRegistrationsPerIPAddress:64.112.117.1' burst: 40 count: 40 period: 1s
 func newTestTransactionBuilder(t *testing.T) *TransactionBuilder {
-	c, err := NewTransactionBuilderFromFiles("testdata/working_default.yml", "testdata/working_override.yml", metrics.NoopRegisterer, blog.NewMock())
+	c, err := NewTransactionBuilderFromFiles("testdata/working_default.yml", false, RoundTruncate, "testdata/working_override.yml", false, metrics.NoopRegisterer, blog.NewMock())
 	test.AssertNotError(t, err, "should not error")
 	err = c.loadOverrides(context.Background())
 	test.AssertNotError(t, err, "loading overrides")
@@ -606,6 +607,11 @@ func TestRateLimitError(t *testing.T) {
 				test.AssertError(t, err, "expected an error")
 				test.AssertEquals(t, err.Error(), tc.expectedErr)
 				test.AssertErrorIs(t, err, tc.expectedErrType)
+				if tc.expectedErrType == berrors.RateLimit {
+					bErr, ok := errors.AsType[*berrors.BoulderError](err)
+					test.Assert(t, ok, "expected a BoulderError")
+					test.AssertEquals(t, bErr.RateLimitName(), tc.decision.transaction.limit.Name.String())
+				}
 			}
 		})
 	}
@@ -625,7 +631,7 @@ func TestStricterDeniedBeatsAllowed(t *testing.T) {
 		Period: config.Duration{Duration: time.Second},
 		Name:   NewRegistrationsPerIPAddress,
 	}
-	limitA.precompute()
+	limitA.precompute(RoundTruncate)
 
 	// Limit B, our slow limit, permits 2 requests per hour. An allowed decision
 	// from this limit will have a retryIn up to 30 minutes, far exceeding any
@@ -636,7 +642,7 @@ func TestStricterDeniedBeatsAllowed(t *testing.T) {
 		Period: config.Duration{Duration: time.Hour},
 		Name:   NewRegistrationsPerIPv6Range,
 	}
-	limitB.precompute()
+	limitB.precompute(RoundTruncate)
 
 	bucketKeyA := "limitA:testkey"
 	bucketKeyB := "limitB:testkey"
@@ -17,6 +17,7 @@ import (
 //
 // IMPORTANT: If you add or remove a limit Name, you MUST update:
 //   - the string representation of the Name in nameToString,
+//   - the category of the Name in nameToCategory,
 //   - the validators for that name in validateIdForName(),
 //   - the transaction constructors for that name in transaction.go
 //   - the Subscriber facing error message in Decision.Result(), and
@@ -120,6 +121,28 @@ var nameToString = map[Name]string{
 	LimitOverrideRequestsPerIPAddress:                 "LimitOverrideRequestsPerIPAddress",
 }
 
+// Categories used to group rate limit names, e.g. for display in dashboards.
+// These strings are stable and may be relied upon by external consumers.
+const (
+	CategoryRegistration         = "registration"
+	CategoryIssuance             = "issuance"
+	CategoryAuthorizationFailure = "authorization-failure"
+	CategoryOverrideRequest      = "override-request"
+)
+
+// nameToCategory is a map of Name values to the category they belong to.
+var nameToCategory = map[Name]string{
+	NewRegistrationsPerIPAddress:                      CategoryRegistration,
+	NewRegistrationsPerIPv6Range:                      CategoryRegistration,
+	NewOrdersPerAccount:                               CategoryIssuance,
+	FailedAuthorizationsPerDomainPerAccount:           CategoryAuthorizationFailure,
+	CertificatesPerDomain:                             CategoryIssuance,
+	CertificatesPerDomainPerAccount:                   CategoryIssuance,
+	CertificatesPerFQDNSet:                            CategoryIssuance,
+	FailedAuthorizationsForPausingPerDomainPerAccount: CategoryAuthorizationFailure,
+	LimitOverrideRequestsPerIPAddress:                 CategoryOverrideRequest,
+}
+
 // isValid returns true if the Name is a valid rate limit name.
 func (n Name) isValid() bool {
 	return n > Unknown && n < Name(len(nameToString))
@@ -142,6 +165,15 @@ func (n Name) EnumString() string {
 	return strconv.Itoa(int(n))
 }
 
+// Category returns the category of the Name, or the empty string if the Name
+// is not valid.
+func (n Name) Category() string {
+	if !n.isValid() {
+		return ""
+	}
+	return nameToCategory[n]
+}
+
 // validIPAddress validates that the provided string is a valid IP address.
 func validIPAddress(id string) error {
 	ip, err := netip.ParseAddr(id)
@@ -212,8 +244,11 @@ func validateRegIdIdentValue(id string) error {
 }
 
 // validateDomainOrCIDR validates that the provided string is either a domain
-// name or an IP address. IPv6 addresses must be the lowest address in their
-// /64, i.e. their last 64 bits must be zero.
+// name, an IP address, or an IP prefix in CIDR notation. IPv6 addresses must be
+// the lowest address in their /64, i.e. their last 64 bits must be zero.
+// Prefixes must be the same size as the prefix covering an IP address for this
+// limit (/32 for IPv4, /64 for IPv6), but may have host bits set; these are
+// cleared by hydrateOverrideLimit.
 func validateDomainOrCIDR(limit Name, id string) error {
 	domainErr := policy.ValidDomain(id)
 	if domainErr == nil {
@@ -221,6 +256,10 @@ func validateDomainOrCIDR(limit Name, id string) error {
 		return nil
 	}
 
+	if strings.Contains(id, "/") {
+		return validateCoveringPrefix(limit, id)
+	}
+
 	ip, ipErr := netip.ParseAddr(id)
 	if ipErr != nil {
 		return fmt.Errorf("%q is neither a domain (%w) nor an IP address (%w)", id, domainErr, ipErr)
@@ -240,6 +279,43 @@ func validateDomainOrCIDR(limit Name, id string) error {
 	return iana.IsReservedPrefix(prefix)
 }
 
+// validateCoveringPrefix validates that the provided string is an IP prefix in
+// CIDR notation whose size matches that of the prefix covering an IP address
+// for this limit.
+func validateCoveringPrefix(limit Name, id string) error {
+	prefix, err := netip.ParsePrefix(id)
+	if err != nil {
+		return fmt.Errorf("invalid CIDR %q: %w", id, err)
+	}
+	covering, err := coveringIPPrefix(limit, prefix.Addr())
+	if err != nil {
+		return fmt.Errorf("invalid CIDR %q, couldn't determine prefix: %w", id, err)
+	}
+	if prefix.Bits() != covering.Bits() {
+		return fmt.Errorf("invalid CIDR %q, must be /%d", id, covering.Bits())
+	}
+	return iana.IsReservedPrefix(prefix.Masked())
+}
+
+// validateOverridePrefix validates that the provided string is an IP prefix in
+// CIDR notation which is the same size as, or larger than, the prefix covering
+// an IP address for this limit. Unlike transaction bucket keys, overrides may
+// cover more than one such prefix.
+func validateOverridePrefix(limit Name, id string) error {
+	prefix, err := netip.ParsePrefix(id)
+	if err != nil {
+		return fmt.Errorf("invalid CIDR %q: %w", id, err)
+	}
+	covering, err := coveringIPPrefix(limit, prefix.Addr())
+	if err != nil {
+		return fmt.Errorf("invalid CIDR %q, couldn't determine prefix: %w", id, err)
+	}
+	if prefix.Bits() > covering.Bits() {
+		return fmt.Errorf("invalid CIDR %q, must be /%d or shorter", id, covering.Bits())
+	}
+	return iana.IsReservedPrefix(prefix.Masked())
+}
+
 // validateRegIdDomainOrCIDR validates that the provided string is formatted
 // 'regId:domainOrCIDR', where domainOrCIDR is either a domain name or an IP
 // address. IPv6 addresses must be the lowest address in their /64, i.e. their
@@ -282,6 +358,13 @@ func validateFQDNSet(id string) error {
 	return nil
 }
 
+// ValidateOverrideID returns an error if id is not a valid override id for the
+// limit with the provided name. It allows tooling to check the ids in an
+// overrides file without building a full limit registry.
+func ValidateOverrideID(name Name, id string) error {
+	return validateIdForName(name, id)
+}
+
 func validateIdForName(name Name, id string) error {
 	switch name {
 	case NewRegistrationsPerIPAddress, LimitOverrideRequestsPerIPAddress:
@@ -316,6 +399,9 @@ func validateIdForName(name Name, id string) error {
 
 	case CertificatesPerDomain:
 		// 'enum:domainOrCIDR'
+		if strings.Contains(id, "/") {
+			return validateOverridePrefix(name, id)
+		}
 		return validateDomainOrCIDR(name, id)
 
 	case CertificatesPerFQDNSet:
@@ -358,6 +444,16 @@ var LimitNames = func() []string {
 	return names
 }()
 
+// LimitNamesByCategory returns a map of category to all rate limit names in
+// that category. Names within each category are in ascending enum order.
+func LimitNamesByCategory() map[string][]Name {
+	m := make(map[string][]Name)
+	for n := Unknown + 1; n.isValid(); n++ {
+		m[n.Category()] = append(m[n.Category()], n)
+	}
+	return m
+}
+
 // BuildBucketKey builds a bucketKey for the given rate limit name from the
 // provided components. It returns an error if the name is not valid or if the
 // components are not valid for the given name.
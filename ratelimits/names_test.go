@@ -32,6 +32,27 @@ func TestNameIsValid(t *testing.T) {
 	}
 }
 
+func TestNameCategory(t *testing.T) {
+	t.Parallel()
+
+	for n := Unknown + 1; n.isValid(); n++ {
+		test.Assert(t, n.Category() != "", fmt.Sprintf("limit %s has no category", n))
+	}
+	test.AssertEquals(t, Unknown.Category(), "")
+	test.AssertEquals(t, Name(9001).Category(), "")
+
+	byCategory := LimitNamesByCategory()
+	var total int
+	for category, names := range byCategory {
+		for _, n := range names {
+			test.AssertEquals(t, n.Category(), category)
+		}
+		total += len(names)
+	}
+	test.AssertEquals(t, total, len(nameToString)-1)
+	test.AssertDeepEquals(t, byCategory[CategoryRegistration], []Name{NewRegistrationsPerIPAddress, NewRegistrationsPerIPv6Range})
+}
+
 func TestValidateIdForName(t *testing.T) {
 	t.Parallel()
 
@@ -240,9 +261,46 @@ func TestValidateIdForName(t *testing.T) {
 		},
 		{
 			limit: CertificatesPerDomain,
-			desc:  "IPv6 address with subnet",
+			desc:  "valid IPv4 prefix",
+			id:    "64.112.117.1/32",
+		},
+		{
+			limit: CertificatesPerDomain,
+			desc:  "valid IPv6 prefix",
 			id:    "2602:80a:6000::/64",
-			err:   "nor an IP address",
+		},
+		{
+			limit: CertificatesPerDomain,
+			desc:  "IPv6 prefix with host bits",
+			id:    "2602:80a:6000::1/64",
+		},
+		{
+			limit: CertificatesPerDomain,
+			desc:  "IPv4 prefix larger than the covering prefix",
+			id:    "64.112.117.0/24",
+		},
+		{
+			limit: CertificatesPerDomain,
+			desc:  "IPv6 prefix larger than the covering prefix",
+			id:    "2602:80a:6000::/40",
+		},
+		{
+			limit: CertificatesPerDomain,
+			desc:  "IPv6 prefix smaller than the covering prefix",
+			id:    "2602:80a:6000::/80",
+			err:   "must be /64 or shorter",
+		},
+		{
+			limit: CertificatesPerDomain,
+			desc:  "reserved IPv4 prefix",
+			id:    "192.0.2.0/24",
+			err:   "reserved address block",
+		},
+		{
+			limit: CertificatesPerDomain,
+			desc:  "malformed prefix",
+			id:    "2602:80a:6000::/sixty-four",
+			err:   "invalid CIDR",
 		},
 		{
 			limit: CertificatesPerDomain,
@@ -297,6 +355,19 @@ func TestValidateIdForName(t *testing.T) {
 	}
 }
 
+func TestValidateOverrideID(t *testing.T) {
+	t.Parallel()
+
+	err := ValidateOverrideID(NewOrdersPerAccount, "12345678")
+	test.AssertNotError(t, err, "valid account id should have succeeded")
+
+	err = ValidateOverrideID(NewRegistrationsPerIPAddress, "64.112.117.1")
+	test.AssertNotError(t, err, "valid IP address should have succeeded")
+
+	err = ValidateOverrideID(NewRegistrationsPerIPAddress, "12345678")
+	test.AssertError(t, err, "account id should not be valid for an IP address limit")
+}
+
 func TestBuildBucketKey(t *testing.T) {
 	t.Parallel()
 
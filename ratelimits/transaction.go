@@ -58,10 +58,17 @@ func newRegIdIdentValueBucketKey(name Name, regId int64, orderIdent string) stri
 	return joinWithColon(name.EnumString(), strconv.FormatInt(regId, 10), orderIdent)
 }
 
+// FQDNSetOverrideID returns the id, within the 'enum:fqdnSet' bucket key
+// format, for the provided set of identifiers. It matches the id computed for
+// a CertificatesPerFQDNSet override listing the same identifier values.
+func FQDNSetOverrideID(idents identifier.ACMEIdentifiers) string {
+	return fmt.Sprintf("%x", core.HashIdentifiers(idents))
+}
+
 // newFQDNSetBucketKey validates and returns a bucketKey for limits that use the
 // 'enum:fqdnSet' bucket key format.
 func newFQDNSetBucketKey(name Name, orderIdents identifier.ACMEIdentifiers) string {
-	return joinWithColon(name.EnumString(), fmt.Sprintf("%x", core.HashIdentifiers(orderIdents)))
+	return joinWithColon(name.EnumString(), FQDNSetOverrideID(orderIdents))
 }
 
 // Transaction represents a single rate limit operation. It includes a
@@ -197,10 +204,13 @@ type GetOverridesFunc func(context.Context, *emptypb.Empty, ...grpc.CallOption)
 
 // NewTransactionBuilderFromDatabase returns a new *TransactionBuilder. The
 // provided defaults path is expected to be a path to a YAML file that contains
-// the default limits. The provided overrides function is expected to be an SA's
-// GetEnabledRateLimitOverrides. Both are required.
-func NewTransactionBuilderFromDatabase(defaults string, overrides GetOverridesFunc, stats prometheus.Registerer, logger blog.Logger) (*TransactionBuilder, error) {
-	defaultsData, err := loadDefaultsFromFile(defaults)
+// the default limits. If expandEnvInDefaults is true, ${VAR} references in that
+// file are replaced with the values of the corresponding environment variables.
+// Emission intervals are rounded according to the provided mode. The provided
+// overrides function is expected to be an SA's GetEnabledRateLimitOverrides.
+// Both are required.
+func NewTransactionBuilderFromDatabase(defaults string, expandEnvInDefaults bool, rounding RoundingMode, overrides GetOverridesFunc, stats prometheus.Registerer, logger blog.Logger) (*TransactionBuilder, error) {
+	defaultsData, err := loadDefaultsFromFile(defaults, expandEnvInDefaults)
 	if err != nil {
 		return nil, err
 	}
@@ -246,21 +256,26 @@ func NewTransactionBuilderFromDatabase(defaults string, overrides GetOverridesFu
 		return overrides, nil
 	}
 
-	return NewTransactionBuilder(defaultsData, refresher, stats, logger)
+	return NewTransactionBuilder(defaultsData, rounding, refresher, stats, logger)
 }
 
 // NewTransactionBuilderFromFiles returns a new *TransactionBuilder. The
 // provided defaults and overrides paths are expected to be paths to YAML files
 // that contain the default and override limits, respectively. Overrides is
-// optional, defaults is required.
-func NewTransactionBuilderFromFiles(defaults string, overrides string, stats prometheus.Registerer, logger blog.Logger) (*TransactionBuilder, error) {
-	defaultsData, err := loadDefaultsFromFile(defaults)
+// optional, defaults is required. If expandEnvInDefaults is true, ${VAR}
+// references in the defaults file are replaced with the values of the
+// corresponding environment variables. Emission intervals are rounded
+// according to the provided mode. Overrides which resolve to the same bucket
+// key are rejected unless allowDuplicateOverrides is true, in which case the
+// last one wins.
+func NewTransactionBuilderFromFiles(defaults string, expandEnvInDefaults bool, rounding RoundingMode, overrides string, allowDuplicateOverrides bool, stats prometheus.Registerer, logger blog.Logger) (*TransactionBuilder, error) {
+	defaultsData, err := loadDefaultsFromFile(defaults, expandEnvInDefaults)
 	if err != nil {
 		return nil, err
 	}
 
 	if overrides == "" {
-		return NewTransactionBuilder(defaultsData, nil, stats, logger)
+		return NewTransactionBuilder(defaultsData, rounding, nil, stats, logger)
 	}
 
 	refresher := func(ctx context.Context, _ prometheus.Gauge, _ blog.Logger) (Limits, error) {
@@ -268,16 +283,17 @@ func NewTransactionBuilderFromFiles(defaults string, overrides string, stats pro
 		if err != nil {
 			return nil, err
 		}
-		return parseOverrideLimits(overridesData)
+		return parseOverrideLimits(overridesData, allowDuplicateOverrides)
 	}
 
-	return NewTransactionBuilder(defaultsData, refresher, stats, logger)
+	return NewTransactionBuilder(defaultsData, rounding, refresher, stats, logger)
 }
 
 // NewTransactionBuilder returns a new *TransactionBuilder. A defaults map is
-// required.
-func NewTransactionBuilder(defaultConfigs LimitConfigs, refresher OverridesRefresher, stats prometheus.Registerer, logger blog.Logger) (*TransactionBuilder, error) {
-	defaults, err := parseDefaultLimits(defaultConfigs)
+// required. Emission intervals for both defaults and overrides are rounded
+// according to the provided mode.
+func NewTransactionBuilder(defaultConfigs LimitConfigs, rounding RoundingMode, refresher OverridesRefresher, stats prometheus.Registerer, logger blog.Logger) (*TransactionBuilder, error) {
+	defaults, err := parseDefaultLimits(defaultConfigs, rounding)
 	if err != nil {
 		return nil, err
 	}
@@ -309,14 +325,22 @@ func NewTransactionBuilder(defaultConfigs LimitConfigs, refresher OverridesRefre
 		Help:      "A gauge with the number of overrides, partitioned by rate limit",
 	}, []string{"limit"})
 
+	lookups := promauto.With(stats).NewCounterVec(prometheus.CounterOpts{
+		Namespace: "ratelimits",
+		Name:      "limit_lookups",
+		Help:      "A counter of rate limit lookups, partitioned by limit name and by result (override, default, or disabled)",
+	}, []string{"name", "result"})
+
 	registry := &limitRegistry{
 		defaults:         defaults,
 		refreshOverrides: refresher,
+		rounding:         rounding,
 		logger:           logger,
 
 		overridesTimestamp: overridesTimestamp,
 		overridesErrors:    overridesErrors,
 		overridesPerLimit:  *overridesPerLimit,
+		lookups:            lookups,
 	}
 
 	return &TransactionBuilder{registry}, nil
@@ -26,13 +26,31 @@ import (
 
 func TestNewTransactionBuilderFromFiles_WithBadLimitsPath(t *testing.T) {
 	t.Parallel()
-	_, err := NewTransactionBuilderFromFiles("testdata/does-not-exist.yml", "", metrics.NoopRegisterer, blog.NewMock())
+	_, err := NewTransactionBuilderFromFiles("testdata/does-not-exist.yml", false, RoundTruncate, "", false, metrics.NoopRegisterer, blog.NewMock())
 	test.AssertError(t, err, "should error")
 
-	_, err = NewTransactionBuilderFromFiles("testdata/defaults.yml", "testdata/does-not-exist.yml", metrics.NoopRegisterer, blog.NewMock())
+	_, err = NewTransactionBuilderFromFiles("testdata/defaults.yml", false, RoundTruncate, "testdata/does-not-exist.yml", false, metrics.NoopRegisterer, blog.NewMock())
 	test.AssertError(t, err, "should error")
 }
 
+func TestNewTransactionBuilderFromFiles_DuplicateOverrides(t *testing.T) {
+	t.Parallel()
+
+	tb, err := NewTransactionBuilderFromFiles("testdata/working_default.yml", false, RoundTruncate, "testdata/busted_overrides_duplicate_id.yml", false, metrics.NoopRegisterer, blog.NewMock())
+	test.AssertNotError(t, err, "creating TransactionBuilder")
+	err = tb.loadOverrides(context.Background())
+	test.AssertError(t, err, "duplicate overrides should be rejected by default")
+	test.AssertContains(t, err.Error(), "duplicate bucket key")
+
+	tb, err = NewTransactionBuilderFromFiles("testdata/working_default.yml", false, RoundTruncate, "testdata/busted_overrides_duplicate_id.yml", true, metrics.NoopRegisterer, blog.NewMock())
+	test.AssertNotError(t, err, "creating TransactionBuilder")
+	err = tb.loadOverrides(context.Background())
+	test.AssertNotError(t, err, "duplicate overrides should be allowed when configured")
+	limit, err := tb.getLimit(NewRegistrationsPerIPAddress, joinWithColon(NewRegistrationsPerIPAddress.EnumString(), "64.112.117.1"))
+	test.AssertNotError(t, err, "getting override limit")
+	test.AssertEquals(t, limit.Burst, int64(50))
+}
+
 func sortTransactions(txns []Transaction) []Transaction {
 	sort.Slice(txns, func(i, j int) bool {
 		return txns[i].bucketKey < txns[j].bucketKey
@@ -43,7 +61,7 @@ func sortTransactions(txns []Transaction) []Transaction {
 func TestNewRegistrationsPerIPAddressTransactions(t *testing.T) {
 	t.Parallel()
 
-	tb, err := NewTransactionBuilderFromFiles("../test/config-next/ratelimit-defaults.yml", "", metrics.NoopRegisterer, blog.NewMock())
+	tb, err := NewTransactionBuilderFromFiles("../test/config-next/ratelimit-defaults.yml", false, RoundTruncate, "", false, metrics.NoopRegisterer, blog.NewMock())
 	test.AssertNotError(t, err, "creating TransactionBuilder")
 
 	// A check-and-spend transaction for the global limit.
@@ -56,7 +74,7 @@ func TestNewRegistrationsPerIPAddressTransactions(t *testing.T) {
 func TestNewRegistrationsPerIPv6AddressTransactions(t *testing.T) {
 	t.Parallel()
 
-	tb, err := NewTransactionBuilderFromFiles("../test/config-next/ratelimit-defaults.yml", "", metrics.NoopRegisterer, blog.NewMock())
+	tb, err := NewTransactionBuilderFromFiles("../test/config-next/ratelimit-defaults.yml", false, RoundTruncate, "", false, metrics.NoopRegisterer, blog.NewMock())
 	test.AssertNotError(t, err, "creating TransactionBuilder")
 
 	// A check-and-spend transaction for the global limit.
@@ -69,7 +87,7 @@ func TestNewRegistrationsPerIPv6AddressTransactions(t *testing.T) {
 func TestNewOrdersPerAccountTransactions(t *testing.T) {
 	t.Parallel()
 
-	tb, err := NewTransactionBuilderFromFiles("../test/config-next/ratelimit-defaults.yml", "", metrics.NoopRegisterer, blog.NewMock())
+	tb, err := NewTransactionBuilderFromFiles("../test/config-next/ratelimit-defaults.yml", false, RoundTruncate, "", false, metrics.NoopRegisterer, blog.NewMock())
 	test.AssertNotError(t, err, "creating TransactionBuilder")
 
 	// A check-and-spend transaction for the global limit.
@@ -82,7 +100,7 @@ func TestNewOrdersPerAccountTransactions(t *testing.T) {
 func TestFailedAuthorizationsPerDomainPerAccountTransactions(t *testing.T) {
 	t.Parallel()
 
-	tb, err := NewTransactionBuilderFromFiles("../test/config-next/ratelimit-defaults.yml", "testdata/working_override_13371338.yml", metrics.NoopRegisterer, blog.NewMock())
+	tb, err := NewTransactionBuilderFromFiles("../test/config-next/ratelimit-defaults.yml", false, RoundTruncate, "testdata/working_override_13371338.yml", false, metrics.NoopRegisterer, blog.NewMock())
 	test.AssertNotError(t, err, "creating TransactionBuilder")
 	err = tb.loadOverrides(context.Background())
 	test.AssertNotError(t, err, "loading overrides")
@@ -121,7 +139,7 @@ func TestFailedAuthorizationsPerDomainPerAccountTransactions(t *testing.T) {
 func TestFailedAuthorizationsForPausingPerDomainPerAccountTransactions(t *testing.T) {
 	t.Parallel()
 
-	tb, err := NewTransactionBuilderFromFiles("../test/config-next/ratelimit-defaults.yml", "testdata/working_override_13371338.yml", metrics.NoopRegisterer, blog.NewMock())
+	tb, err := NewTransactionBuilderFromFiles("../test/config-next/ratelimit-defaults.yml", false, RoundTruncate, "testdata/working_override_13371338.yml", false, metrics.NoopRegisterer, blog.NewMock())
 	test.AssertNotError(t, err, "creating TransactionBuilder")
 	err = tb.loadOverrides(context.Background())
 	test.AssertNotError(t, err, "loading overrides")
@@ -137,7 +155,7 @@ func TestFailedAuthorizationsForPausingPerDomainPerAccountTransactions(t *testin
 func TestCertificatesPerDomainTransactions(t *testing.T) {
 	t.Parallel()
 
-	tb, err := NewTransactionBuilderFromFiles("../test/config-next/ratelimit-defaults.yml", "", metrics.NoopRegisterer, blog.NewMock())
+	tb, err := NewTransactionBuilderFromFiles("../test/config-next/ratelimit-defaults.yml", false, RoundTruncate, "", false, metrics.NoopRegisterer, blog.NewMock())
 	test.AssertNotError(t, err, "creating TransactionBuilder")
 
 	// One check-only transaction for the global limit.
@@ -158,7 +176,7 @@ func TestCertificatesPerDomainTransactions(t *testing.T) {
 func TestCertificatesPerDomainPerAccountTransactions(t *testing.T) {
 	t.Parallel()
 
-	tb, err := NewTransactionBuilderFromFiles("../test/config-next/ratelimit-defaults.yml", "testdata/working_override_13371338.yml", metrics.NoopRegisterer, blog.NewMock())
+	tb, err := NewTransactionBuilderFromFiles("../test/config-next/ratelimit-defaults.yml", false, RoundTruncate, "testdata/working_override_13371338.yml", false, metrics.NoopRegisterer, blog.NewMock())
 	test.AssertNotError(t, err, "creating TransactionBuilder")
 	err = tb.loadOverrides(context.Background())
 	test.AssertNotError(t, err, "loading overrides")
@@ -211,7 +229,7 @@ func TestCertificatesPerDomainPerAccountTransactions(t *testing.T) {
 func TestCertificatesPerFQDNSetTransactions(t *testing.T) {
 	t.Parallel()
 
-	tb, err := NewTransactionBuilderFromFiles("../test/config-next/ratelimit-defaults.yml", "", metrics.NoopRegisterer, blog.NewMock())
+	tb, err := NewTransactionBuilderFromFiles("../test/config-next/ratelimit-defaults.yml", false, RoundTruncate, "", false, metrics.NoopRegisterer, blog.NewMock())
 	test.AssertNotError(t, err, "creating TransactionBuilder")
 
 	// A single check-only transaction for the global limit.
@@ -236,7 +254,7 @@ func TestNewTransactionBuilder(t *testing.T) {
 			Burst:  expectedBurst,
 			Count:  expectedCount,
 			Period: expectedPeriod},
-	}, nil, metrics.NoopRegisterer, blog.NewMock())
+	}, RoundTruncate, nil, metrics.NoopRegisterer, blog.NewMock())
 	test.AssertNotError(t, err, "creating TransactionBuilder")
 
 	newRegDefault, ok := tb.limitRegistry.defaults[NewRegistrationsPerIPAddress.EnumString()]
@@ -314,7 +332,7 @@ func TestNewTransactionBuilderFromDatabase(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			mockLog := blog.NewMock()
-			tb, err := NewTransactionBuilderFromDatabase("../test/config-next/ratelimit-defaults.yml", tc.overrides, metrics.NoopRegisterer, mockLog)
+			tb, err := NewTransactionBuilderFromDatabase("../test/config-next/ratelimit-defaults.yml", false, RoundTruncate, tc.overrides, metrics.NoopRegisterer, mockLog)
 			test.AssertNotError(t, err, "creating TransactionBuilder")
 			err = tb.limitRegistry.loadOverrides(context.Background())
 			if tc.expectError != "" {
@@ -2,6 +2,8 @@ package revocation
 
 import (
 	"fmt"
+
+	berrors "github.com/letsencrypt/boulder/errors"
 )
 
 // Reason is used to specify a certificate revocation reason
@@ -92,3 +94,21 @@ func AdminAllowedReason(r Reason) bool {
 	}
 	return false
 }
+
+// ValidateRevocationReason returns a BadRevocationReason error if the given
+// Reason is not one which the Baseline Requirements (Section 7.2.2) permit to
+// appear in a CRL entry for a Subscriber certificate. Both UserAllowedReason
+// and AdminAllowedReason are stricter; this is a last line of defense for code
+// which writes revocation records.
+func ValidateRevocationReason(r Reason) error {
+	switch r {
+	case Unspecified,
+		KeyCompromise,
+		AffiliationChanged,
+		Superseded,
+		CessationOfOperation,
+		PrivilegeWithdrawn:
+		return nil
+	}
+	return berrors.BadRevocationReasonError(int64(r))
+}
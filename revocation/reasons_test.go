@@ -0,0 +1,20 @@
+package revocation
+
+import (
+	"testing"
+
+	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestValidateRevocationReason(t *testing.T) {
+	t.Parallel()
+
+	for _, r := range []Reason{Unspecified, KeyCompromise, AffiliationChanged, Superseded, CessationOfOperation, PrivilegeWithdrawn} {
+		test.AssertNotError(t, ValidateRevocationReason(r), r.String())
+	}
+	for _, r := range []Reason{CACompromise, CertificateHold, RemoveFromCRL, AACompromise, Reason(7), Reason(11)} {
+		err := ValidateRevocationReason(r)
+		test.AssertErrorIs(t, err, berrors.BadRevocationReason)
+	}
+}
@@ -253,3 +253,7 @@ ALTER TABLE `orders`
   ADD COLUMN `mtcSerialNumber` bigint(20) unsigned DEFAULT NULL;
 
 ALTER TABLE `authz2` ADD COLUMN `beganProcessing` tinyint(1) NOT NULL DEFAULT 0;
+
+ALTER TABLE `certificateStatus` ADD COLUMN `revokedComment` varchar(255) DEFAULT NULL;
+
+ALTER TABLE `replacementOrders` ADD COLUMN `replacedAt` datetime DEFAULT NULL;
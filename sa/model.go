@@ -2,19 +2,28 @@ package sa
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
 	"crypto/x509"
 	"database/sql"
 	"encoding/base64"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"math"
+	"math/big"
 	"net/netip"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-jose/go-jose/v4"
+	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -23,6 +32,8 @@ import (
 	corepb "github.com/letsencrypt/boulder/core/proto"
 	"github.com/letsencrypt/boulder/db"
 	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/features"
+	"github.com/letsencrypt/boulder/goodkey"
 	"github.com/letsencrypt/boulder/grpc"
 	"github.com/letsencrypt/boulder/identifier"
 	"github.com/letsencrypt/boulder/probs"
@@ -77,6 +88,40 @@ func selectRegistration(ctx context.Context, s db.OneSelector, whereCol string,
 	return &model, err
 }
 
+// SelectRegistrationByID selects the registration with the given ID. If no
+// such registration exists, a NotFound error is returned.
+func SelectRegistrationByID(ctx context.Context, s db.OneSelector, id int64) (*corepb.Registration, error) {
+	model, err := selectRegistration(ctx, s, "id", id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, berrors.NotFoundError("registration with ID %d not found", id)
+		}
+		return nil, err
+	}
+	return registrationModelToPb(model)
+}
+
+// DeactivateRegistration sets the status of the registration with the given ID
+// to deactivated, unless it is already deactivated. It returns true if the
+// status was changed, and false if the registration was already deactivated or
+// does not exist.
+func DeactivateRegistration(ctx context.Context, e db.Execer, regID int64) (bool, error) {
+	result, err := e.ExecContext(ctx,
+		"UPDATE registrations SET status = ? WHERE id = ? AND status != ? LIMIT 1",
+		string(core.StatusDeactivated),
+		regID,
+		string(core.StatusDeactivated),
+	)
+	if err != nil {
+		return false, fmt.Errorf("deactivating account %d: %w", regID, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("deactivating account %d: %w", regID, err)
+	}
+	return rowsAffected > 0, nil
+}
+
 const certFields = "id, registrationID, serial, digest, der, issued, expires"
 
 // SelectCertificate selects all fields of one certificate object identified by
@@ -110,6 +155,59 @@ func SelectPrecertificate(ctx context.Context, s db.OneSelector, serial string)
 	return model.toPb(), nil
 }
 
+// SelectPrecertificateDER selects only the DER of one precertificate identified
+// by serial. If no precertificate with that serial exists, a NotFound error is
+// returned.
+func SelectPrecertificateDER(ctx context.Context, s db.OneSelector, serial string) ([]byte, error) {
+	var der []byte
+	err := s.SelectOne(
+		ctx,
+		&der,
+		"SELECT der FROM precertificates WHERE serial = ? LIMIT 1",
+		serial)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, berrors.NotFoundError("precertificate with serial %q not found", serial)
+		}
+		return nil, err
+	}
+	return der, nil
+}
+
+// SelectRegistrationIDForSerial returns the ID of the account which was issued
+// the certificate with the given serial. If there is no final certificate with
+// that serial, the precertificate is used instead. If there is neither, an
+// UnknownSerialError is returned.
+func SelectRegistrationIDForSerial(ctx context.Context, s db.OneSelector, serial string) (int64, error) {
+	var regID int64
+	err := s.SelectOne(
+		ctx,
+		&regID,
+		"SELECT registrationID FROM certificates WHERE serial = ? LIMIT 1",
+		serial,
+	)
+	if err == nil {
+		return regID, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+
+	err = s.SelectOne(
+		ctx,
+		&regID,
+		"SELECT registrationID FROM precertificates WHERE serial = ? LIMIT 1",
+		serial,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, berrors.UnknownSerialError()
+		}
+		return 0, err
+	}
+	return regID, nil
+}
+
 // SelectCertificates selects all fields of multiple certificate objects
 //
 // Returns a slice of *corepb.Certificate along with the highest ID field seen
@@ -132,6 +230,194 @@ func SelectCertificates(ctx context.Context, s db.Selector, q string, args map[s
 	return pbs, highestID, err
 }
 
+// SelectCertificatesByRegistrationID selects up to limit certificates issued to
+// the given account with an ID greater than sinceID, in ascending ID order. It
+// also returns the highest ID seen, which can be passed as sinceID to fetch the
+// next page. If there are no more certificates, it returns a nil slice and a
+// highest ID of 0.
+func SelectCertificatesByRegistrationID(ctx context.Context, s db.Selector, regID int64, sinceID int64, limit int) ([]*corepb.Certificate, int64, error) {
+	if limit <= 0 {
+		return nil, 0, fmt.Errorf("limit must be positive, got %d", limit)
+	}
+	return SelectCertificates(
+		ctx,
+		s,
+		"WHERE registrationID = :regID AND id > :id ORDER BY id ASC LIMIT :limit",
+		map[string]any{
+			"regID": regID,
+			"id":    sinceID,
+			"limit": limit,
+		},
+	)
+}
+
+// expiringCertModel is a row of the certificates table, along with the
+// isExpired column of its certificateStatus row. IsExpired is a pointer
+// because it is NULL if the certificate has no certificateStatus row.
+type expiringCertModel struct {
+	certificateModel
+	IsExpired *bool `db:"isExpired"`
+}
+
+// SelectExpiringCertificates selects up to limit certificates with an ID
+// greater than sinceID, in ascending ID order, which expire in the window
+// [notAfterStart, notAfterEnd) and are not marked as expired. It also returns
+// the highest ID seen, which can be passed as sinceID to fetch the next page.
+//
+// Certificates without a certificateStatus row are skipped, but still count
+// towards limit and the highest ID seen, so that iteration always makes
+// progress. A short or empty page therefore doesn't necessarily mean there are
+// no more certificates; iteration is complete when the highest ID seen is 0.
+func SelectExpiringCertificates(ctx context.Context, s db.Selector, notAfterStart, notAfterEnd time.Time, sinceID int64, limit int) ([]*corepb.Certificate, int64, error) {
+	if limit <= 0 {
+		return nil, 0, fmt.Errorf("limit must be positive, got %d", limit)
+	}
+	var models []expiringCertModel
+	_, err := s.Select(
+		ctx,
+		&models,
+		`SELECT c.id, c.registrationID, c.serial, c.digest, c.der, c.issued, c.expires, cs.isExpired
+		FROM certificates AS c
+		LEFT JOIN certificateStatus AS cs ON cs.serial = c.serial
+		WHERE c.id > ?
+		AND c.expires >= ?
+		AND c.expires < ?
+		AND (cs.isExpired IS NULL OR cs.isExpired = false)
+		ORDER BY c.id ASC
+		LIMIT ?`,
+		sinceID,
+		notAfterStart,
+		notAfterEnd,
+		limit,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var pbs []*corepb.Certificate
+	var highestID int64
+	for _, m := range models {
+		highestID = max(highestID, m.ID)
+		if m.IsExpired == nil {
+			// No certificateStatus row.
+			continue
+		}
+		pbs = append(pbs, m.toPb())
+	}
+	return pbs, highestID, nil
+}
+
+// StreamCertificates calls fn for each row of the certificates table with an ID
+// greater than sinceID, in ascending ID order. Rows are read in batches of the
+// given size so that the whole table is never held in memory. It stops and
+// returns the error as soon as fn returns an error or ctx is canceled.
+func StreamCertificates(ctx context.Context, s db.Selector, sinceID int64, batch int, fn func(*corepb.Certificate) error) error {
+	if batch <= 0 {
+		return fmt.Errorf("batch size must be positive, got %d", batch)
+	}
+	for {
+		err := ctx.Err()
+		if err != nil {
+			return err
+		}
+		certs, highestID, err := SelectCertificates(
+			ctx,
+			s,
+			"WHERE id > :id ORDER BY id LIMIT :limit",
+			map[string]any{
+				"id":    sinceID,
+				"limit": batch,
+			},
+		)
+		if err != nil {
+			return err
+		}
+		for _, cert := range certs {
+			err = ctx.Err()
+			if err != nil {
+				return err
+			}
+			err = fn(cert)
+			if err != nil {
+				return err
+			}
+		}
+		if len(certs) < batch {
+			return nil
+		}
+		sinceID = highestID
+	}
+}
+
+// revokedCertsPageSize is the number of revokedCertificates rows read by each
+// query in StreamRevokedCerts.
+const revokedCertsPageSize = 1000
+
+// StreamRevokedCerts calls fn for each row of the revokedCertificates table
+// with the given issuerID and shardIdx, in ascending ID order. Rows are read a
+// page at a time so that a large shard is never held in memory. It stops and
+// returns the error as soon as fn returns an error or ctx is canceled.
+func StreamRevokedCerts(ctx context.Context, s db.Selector, issuerID int64, shardIdx int64, fn func(crlEntryModel) error) error {
+	var sinceID int64
+	for {
+		err := ctx.Err()
+		if err != nil {
+			return err
+		}
+		var rows []revokedCertModel
+		_, err = s.Select(
+			ctx,
+			&rows,
+			`SELECT id, issuerID, serial, notAfterHour, shardIdx, revokedDate, revokedReason
+			FROM revokedCertificates
+			WHERE issuerID = ?
+			AND shardIdx = ?
+			AND id > ?
+			ORDER BY id
+			LIMIT ?`,
+			issuerID,
+			shardIdx,
+			sinceID,
+			revokedCertsPageSize,
+		)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			err = ctx.Err()
+			if err != nil {
+				return err
+			}
+			err = fn(crlEntryModel{
+				Serial:        row.Serial,
+				Status:        core.OCSPStatusRevoked,
+				RevokedReason: row.RevokedReason,
+				RevokedDate:   row.RevokedDate,
+			})
+			if err != nil {
+				return err
+			}
+			sinceID = row.ID
+		}
+		if len(rows) < revokedCertsPageSize {
+			return nil
+		}
+	}
+}
+
+// VerifyCertificateDigest checks that the Digest stored alongside a certificate
+// matches its DER. The digest is computed by AddCertificate as
+// core.Fingerprint256 of the DER: the unpadded, URL-safe base64 encoding of
+// its SHA-256 hash. A mismatch indicates that the row has been corrupted or
+// tampered with.
+func VerifyCertificateDigest(c *corepb.Certificate) error {
+	computed := core.Fingerprint256(c.Der)
+	if c.Digest != computed {
+		return fmt.Errorf("certificate %q has digest %q, but its DER has digest %q", c.Serial, c.Digest, computed)
+	}
+	return nil
+}
+
 type CertStatusMetadata struct {
 	ID                    int64             `db:"id"`
 	Serial                string            `db:"serial"`
@@ -160,6 +446,137 @@ func SelectCertificateStatus(ctx context.Context, s db.OneSelector, serial strin
 	return model.toPb(), err
 }
 
+// certStatusBatchSize is the maximum number of serials looked up by a single
+// query in SelectCertificateStatusesRecomputeExpiry.
+const certStatusBatchSize = 100
+
+// SelectCertificateStatusesRecomputeExpiry selects the certificate status of
+// each of the given serials, in batches. Because the stored isExpired column
+// is only updated periodically, the IsExpired field of each result is instead
+// computed from its notAfter relative to now. Serials with no certificate
+// status are omitted from the results.
+func SelectCertificateStatusesRecomputeExpiry(ctx context.Context, s db.Selector, serials []string, now time.Time) ([]*corepb.CertificateStatus, error) {
+	var statuses []*corepb.CertificateStatus
+	for batch := range slices.Chunk(serials, certStatusBatchSize) {
+		params := make([]any, len(batch))
+		for i, serial := range batch {
+			params[i] = serial
+		}
+		var models []certificateStatusModel
+		_, err := s.Select(
+			ctx,
+			&models,
+			fmt.Sprintf("SELECT "+certStatusFields+" FROM certificateStatus WHERE serial IN (%s)",
+				db.QuestionMarks(len(batch))),
+			params...,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for _, model := range models {
+			model.IsExpired = model.NotAfter.Before(now)
+			statuses = append(statuses, model.toPb())
+		}
+	}
+	return statuses, nil
+}
+
+// SelectCertStatusesExpiringBetween selects the metadata of up to limit
+// certificateStatus rows with an ID greater than sinceID whose notAfter falls
+// within the window [start, end], inclusive of both ends. Rows are returned in
+// ascending ID order, regardless of the registration they belong to.
+//
+// Returns the selected rows along with the highest ID seen (which can be used
+// as the sinceID of a subsequent call when iterating in primary key order).
+func SelectCertStatusesExpiringBetween(ctx context.Context, s db.Selector, start, end time.Time, sinceID int64, limit int) ([]CertStatusMetadata, int64, error) {
+	var models []CertStatusMetadata
+	_, err := s.Select(
+		ctx,
+		&models,
+		`SELECT `+certStatusFields+` FROM certificateStatus
+			WHERE notAfter BETWEEN ? AND ?
+			AND id > ?
+			ORDER BY id ASC
+			LIMIT ?`,
+		start,
+		end,
+		sinceID,
+		limit,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	var highestID int64
+	for _, m := range models {
+		if m.ID > highestID {
+			highestID = m.ID
+		}
+	}
+	return models, highestID, nil
+}
+
+// maxRevokedCommentLength is the maximum length, in bytes, of a free-text
+// revocation comment. It matches the width of the revokedComment column.
+const maxRevokedCommentLength = 255
+
+// RevocationStatusModel is the revocation-related subset of a
+// certificateStatus row, including the optional free-text comment recorded by
+// an administrator. The RevokedComment field is a pointer because the column is
+// NULL-able.
+type RevocationStatusModel struct {
+	Serial         string            `db:"serial"`
+	Status         core.OCSPStatus   `db:"status"`
+	RevokedDate    time.Time         `db:"revokedDate"`
+	RevokedReason  revocation.Reason `db:"revokedReason"`
+	RevokedComment *string           `db:"revokedComment"`
+}
+
+// SelectRevocationStatus selects the revocation status, date, reason, and
+// comment of one certificateStatus row identified by serial.
+func SelectRevocationStatus(ctx context.Context, s db.OneSelector, serial string) (*RevocationStatusModel, error) {
+	var model RevocationStatusModel
+	err := s.SelectOne(
+		ctx,
+		&model,
+		"SELECT serial, status, revokedDate, revokedReason, revokedComment FROM certificateStatus WHERE serial = ? LIMIT 1",
+		serial,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, berrors.NotFoundError("no certificate status with serial %s", serial)
+		}
+		return nil, err
+	}
+	return &model, nil
+}
+
+// SetRevocationComment records a free-text comment explaining the revocation of
+// the certificate with the given serial. Comments longer than
+// maxRevokedCommentLength bytes are rejected rather than truncated.
+func SetRevocationComment(ctx context.Context, e db.Execer, serial string, comment string) error {
+	if len(comment) > maxRevokedCommentLength {
+		return berrors.MalformedError(
+			"revocation comment is %d bytes, longer than the maximum of %d",
+			len(comment), maxRevokedCommentLength)
+	}
+	res, err := e.ExecContext(ctx,
+		"UPDATE certificateStatus SET revokedComment = ? WHERE serial = ?",
+		comment,
+		serial,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return berrors.NotFoundError("no certificate status with serial %s", serial)
+	}
+	return nil
+}
+
 var mediumBlobSize = int(math.Pow(2, 24))
 
 type issuedNameModel struct {
@@ -179,7 +596,36 @@ type regModel struct {
 	Status    string    `db:"status"`
 }
 
-func registrationPbToModel(reg *corepb.Registration) (*regModel, error) {
+// validateRegistrationKey checks that the given account key is of a type and
+// size permitted by allowed. It only performs cheap structural checks; the more
+// expensive checks (e.g. Fermat factorization, blocked keys) are the
+// responsibility of the goodkey.KeyPolicy in the WFE and RA.
+func validateRegistrationKey(jwk jose.JSONWebKey, allowed goodkey.AllowedKeys) error {
+	switch k := jwk.Key.(type) {
+	case *rsa.PublicKey:
+		bitLen := k.N.BitLen()
+		switch {
+		case bitLen == 2048 && allowed.RSA2048:
+		case bitLen == 3072 && allowed.RSA3072:
+		case bitLen == 4096 && allowed.RSA4096:
+		default:
+			return berrors.BadPublicKeyError("RSA key size not supported: %d", bitLen)
+		}
+	case *ecdsa.PublicKey:
+		switch {
+		case k.Curve == elliptic.P256() && allowed.ECDSAP256:
+		case k.Curve == elliptic.P384() && allowed.ECDSAP384:
+		case k.Curve == elliptic.P521() && allowed.ECDSAP521:
+		default:
+			return berrors.BadPublicKeyError("ECDSA curve %s not supported", k.Params().Name)
+		}
+	default:
+		return berrors.BadPublicKeyError("unsupported key type %T", jwk.Key)
+	}
+	return nil
+}
+
+func registrationPbToModel(reg *corepb.Registration, allowedKeys goodkey.AllowedKeys) (*regModel, error) {
 	// Even though we don't need to convert from JSON to an in-memory JSONWebKey
 	// for the sake of the `Key` field, we do need to do the conversion in order
 	// to compute the SHA256 key digest.
@@ -188,6 +634,10 @@ func registrationPbToModel(reg *corepb.Registration) (*regModel, error) {
 	if err != nil {
 		return nil, err
 	}
+	err = validateRegistrationKey(jwk, allowedKeys)
+	if err != nil {
+		return nil, err
+	}
 	sha, err := core.KeyDigestB64(jwk.Key)
 	if err != nil {
 		return nil, err
@@ -298,6 +748,20 @@ func (model certificateStatusModel) toPb() *corepb.CertificateStatus {
 	}
 }
 
+// NewCertificateStatusForTest returns the CertificateStatus proto which would
+// be read from a certificateStatus row with the given fields. It is intended
+// for tests which need a realistic CertificateStatus without a database.
+func NewCertificateStatusForTest(serial string, status core.OCSPStatus, notAfter time.Time, revokedDate time.Time, revokedReason revocation.Reason, issuerID int64) *corepb.CertificateStatus {
+	return certificateStatusModel{
+		Serial:        serial,
+		Status:        status,
+		RevokedDate:   revokedDate,
+		RevokedReason: revokedReason,
+		NotAfter:      notAfter,
+		IssuerID:      issuerID,
+	}.toPb()
+}
+
 // orderModel represents one row in the orders table.
 type orderModel struct {
 	ID                int64
@@ -316,6 +780,9 @@ type orderModel struct {
 	Authzs []byte
 }
 
+// orderFields is the list of columns selected into an orderModel.
+const orderFields = "id, registrationID, expires, created, error, certificateSerial, beganProcessing, certificateProfileName, replaces, authzs"
+
 func modelToOrder(om *orderModel) (*corepb.Order, error) {
 	profile := ""
 	if om.CertificateProfileName != nil {
@@ -359,6 +826,51 @@ func modelToOrder(om *orderModel) (*corepb.Order, error) {
 	return order, nil
 }
 
+// SelectOrdersByRegistrationID selects up to limit orders belonging to the
+// given account whose IDs are greater than sinceID, in ascending order of ID. To
+// page through all of an account's orders, start with a sinceID of zero and pass
+// the returned cursor as sinceID in the next call. The returned cursor is the ID
+// of the last order selected if the page was full, or zero once there are no
+// more orders.
+func SelectOrdersByRegistrationID(ctx context.Context, s db.Selector, regID int64, sinceID int64, limit int) ([]*corepb.Order, int64, error) {
+	if limit <= 0 {
+		return nil, 0, fmt.Errorf("limit must be positive, got %d", limit)
+	}
+
+	var orderModels []orderModel
+	_, err := s.Select(
+		ctx,
+		&orderModels,
+		`SELECT `+orderFields+`
+		FROM orders
+		WHERE registrationID = ?
+		AND id > ?
+		ORDER BY id ASC
+		LIMIT ?`,
+		regID,
+		sinceID,
+		limit,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var orders []*corepb.Order
+	for _, om := range orderModels {
+		order, err := modelToOrder(&om)
+		if err != nil {
+			return nil, 0, err
+		}
+		orders = append(orders, order)
+	}
+
+	var cursor int64
+	if len(orderModels) == limit {
+		cursor = orderModels[len(orderModels)-1].ID
+	}
+	return orders, cursor, nil
+}
+
 var challTypeToUint = map[string]uint8{
 	"http-01":        0,
 	"dns-01":         1,
@@ -405,6 +917,46 @@ func statusUint(status core.AcmeStatus) uint8 {
 	return statusToUint[status]
 }
 
+// SummarizeAuthorizations returns, for the given registration, the number of
+// unexpired authorizations of each identifier type in each status. Only
+// authorizations which expire after now are counted. It returns an error if
+// the table contains an identifier type or status it does not recognize.
+func SummarizeAuthorizations(ctx context.Context, s db.Selector, regID int64, now time.Time) (map[identifier.IdentifierType]map[core.AcmeStatus]int64, error) {
+	var rows []struct {
+		IdentifierType uint8 `db:"identifierType"`
+		Status         uint8 `db:"status"`
+		Count          int64 `db:"count"`
+	}
+	_, err := s.Select(ctx, &rows,
+		`SELECT identifierType, status, COUNT(*) AS count
+		FROM authz2
+		WHERE registrationID = ? AND expires > ?
+		GROUP BY identifierType, status`,
+		regID,
+		now,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := make(map[identifier.IdentifierType]map[core.AcmeStatus]int64)
+	for _, row := range rows {
+		identType, ok := uintToIdentifierType[row.IdentifierType]
+		if !ok {
+			return nil, fmt.Errorf("unrecognized identifier type encoding %d", row.IdentifierType)
+		}
+		status, ok := uintToStatus[row.Status]
+		if !ok {
+			return nil, fmt.Errorf("unrecognized status encoding %d", row.Status)
+		}
+		if summary[identType] == nil {
+			summary[identType] = make(map[core.AcmeStatus]int64)
+		}
+		summary[identType][status] = row.Count
+	}
+	return summary, nil
+}
+
 // authzFields is used in a variety of places in sa.go, and modifications to
 // it must be carried through to every use in sa.go
 const authzFields = "id, identifierType, identifierValue, registrationID, certificateProfileName, status, expires, challenges, attempted, attemptedAt, token, validationError, validationRecord"
@@ -501,7 +1053,10 @@ func SelectAuthzsMatchingIssuance(
 	// The WHERE clause returned by this function does not contain any
 	// user-controlled strings; all user-controlled input ends up in the
 	// returned placeholder args.
-	identConditions, identArgs := buildIdentifierQueryConditions(idents)
+	identConditions, identArgs, err := buildIdentifierQueryConditions(idents)
+	if err != nil {
+		return nil, err
+	}
 	query := fmt.Sprintf(`SELECT %s FROM authz2 WHERE
 			registrationID = ? AND
 			status IN (?, ?) AND
@@ -520,7 +1075,7 @@ func SelectAuthzsMatchingIssuance(
 	args = append(args, identArgs...)
 
 	var authzModels []authzModel
-	_, err := s.Select(ctx, &authzModels, query, args...)
+	_, err = s.Select(ctx, &authzModels, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -537,6 +1092,46 @@ func SelectAuthzsMatchingIssuance(
 	return authzs, err
 }
 
+// SelectAuthzsExpiringBetween selects up to limit pending authorizations
+// belonging to the given account which expire at or after start and before end,
+// soonest-expiring first.
+func SelectAuthzsExpiringBetween(ctx context.Context, s db.Selector, regID int64, start, end time.Time, limit int) ([]*corepb.Authorization, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive, got %d", limit)
+	}
+
+	var authzModels []authzModel
+	_, err := s.Select(
+		ctx,
+		&authzModels,
+		fmt.Sprintf(`SELECT %s FROM authz2
+			WHERE registrationID = ?
+			AND status = ?
+			AND expires >= ?
+			AND expires < ?
+			ORDER BY expires ASC
+			LIMIT ?`, authzFields),
+		regID,
+		statusToUint[core.StatusPending],
+		start,
+		end,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var authzs []*corepb.Authorization
+	for _, model := range authzModels {
+		authz, err := modelToAuthzPB(model)
+		if err != nil {
+			return nil, err
+		}
+		authzs = append(authzs, authz)
+	}
+	return authzs, nil
+}
+
 // hasMultipleNonPendingChallenges checks if a slice of challenges contains
 // more than one non-pending challenge
 func hasMultipleNonPendingChallenges(challenges []*corepb.Challenge) bool {
@@ -555,8 +1150,9 @@ func hasMultipleNonPendingChallenges(challenges []*corepb.Challenge) bool {
 
 // newAuthzReqToModel converts an sapb.NewAuthzRequest to the authzModel storage
 // representation. It hardcodes the status to "pending" because it should be
-// impossible to create an authz in any other state.
-func newAuthzReqToModel(authz *sapb.NewAuthzRequest, profile string) (*authzModel, error) {
+// impossible to create an authz in any other state. If challTypes is non-nil,
+// it is incremented once for each challenge type offered by the new authz.
+func newAuthzReqToModel(authz *sapb.NewAuthzRequest, profile string, challTypes *prometheus.CounterVec) (*authzModel, error) {
 	am := &authzModel{
 		IdentifierType:  identifierTypeToUint[authz.Identifier.Type],
 		IdentifierValue: authz.Identifier.Value,
@@ -574,15 +1170,70 @@ func newAuthzReqToModel(authz *sapb.NewAuthzRequest, profile string) (*authzMode
 		am.Challenges |= 1 << challTypeToUint[challType]
 	}
 
-	token, err := base64.RawURLEncoding.DecodeString(authz.Token)
+	err := validateChallengesForIdentifierType(am.IdentifierType, am.Challenges)
 	if err != nil {
 		return nil, err
 	}
+
+	token, err := decodeAuthzToken(authz.Token)
+	if err != nil {
+		return nil, err
+	}
+	if len(token) != authzTokenLength {
+		return nil, fmt.Errorf("authorization token decodes to %d bytes, expected %d", len(token), authzTokenLength)
+	}
 	am.Token = token
 
+	if challTypes != nil {
+		for pos, challType := range uintToChallType {
+			if am.Challenges&(1<<pos) != 0 {
+				challTypes.WithLabelValues(challType).Inc()
+			}
+		}
+	}
+
 	return am, nil
 }
 
+// validateChallengesForIdentifierType returns an error if the challenges bitmap
+// includes a challenge type which cannot be used to validate identifiers of
+// the given type. IP identifiers can only be validated by http-01 and
+// tls-alpn-01 (RFC 8738, Section 7), so any DNS-based challenge is rejected.
+func validateChallengesForIdentifierType(identType uint8, challenges uint8) error {
+	if uintToIdentifierType[identType] != identifier.TypeIP {
+		return nil
+	}
+	for _, challType := range []core.AcmeChallenge{
+		core.ChallengeTypeDNS01,
+		core.ChallengeTypeDNSAccount01,
+		core.ChallengeTypeDNSPersist01,
+	} {
+		if challenges&(1<<challTypeToUint[string(challType)]) != 0 {
+			return fmt.Errorf("challenge type %q cannot be used for IP identifiers", challType)
+		}
+	}
+	return nil
+}
+
+// authzTokenLength is the number of random bytes in a token generated by
+// core.NewToken.
+const authzTokenLength = 32
+
+// decodeAuthzToken decodes a base64url-encoded authorization token for storage.
+// Tokens are stored as raw bytes and re-encoded with base64.RawURLEncoding by
+// modelToAuthzPB, so a token which would not survive that round trip unchanged
+// (e.g. one that is padded or has non-zero trailing bits) is rejected.
+func decodeAuthzToken(token string) ([]byte, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+	if base64.RawURLEncoding.EncodeToString(decoded) != token {
+		return nil, fmt.Errorf("authorization token %q is not canonically encoded", token)
+	}
+	return decoded, nil
+}
+
 // authzPBToModel converts a protobuf authorization representation to the
 // authzModel storage representation.
 // Deprecated: this function is only used as part of test setup, do not
@@ -672,7 +1323,7 @@ func authzPBToModel(authz *corepb.Authorization) (*authzModel, error) {
 				}
 			}
 		}
-		token, err := base64.RawURLEncoding.DecodeString(tokenStr)
+		token, err := decodeAuthzToken(tokenStr)
 		if err != nil {
 			return nil, err
 		}
@@ -727,6 +1378,14 @@ func populateAttemptedFields(am authzModel, challenge *corepb.Challenge) error {
 	return nil
 }
 
+// HydrateAuthz converts a row of the authz2 table into a fully-hydrated
+// *corepb.Authorization, expanding the challenge bitmap and rehydrating the
+// Hostname and Port of any HTTP-01 validation records. It is exported for use by
+// tooling which reads authz2 rows directly.
+func HydrateAuthz(am authzModel) (*corepb.Authorization, error) {
+	return modelToAuthzPB(am)
+}
+
 func modelToAuthzPB(am authzModel) (*corepb.Authorization, error) {
 	identType, ok := uintToIdentifierType[am.IdentifierType]
 	if !ok {
@@ -746,6 +1405,12 @@ func modelToAuthzPB(am authzModel) (*corepb.Authorization, error) {
 		Expires:                timestamppb.New(am.Expires),
 		CertificateProfileName: profile,
 	}
+	// The attempted challenge must be one of the challenges offered, otherwise
+	// it would be silently dropped below.
+	if am.Attempted != nil && (am.Challenges>>*am.Attempted)&1 == 0 {
+		return nil, fmt.Errorf("authorization %d has attempted challenge %d which is not in its challenges bitmap %08b",
+			am.ID, *am.Attempted, am.Challenges)
+	}
 	// Populate authorization challenge array. We do this by iterating through
 	// the challenge type bitmap and creating a challenge of each type if its
 	// bit is set. Each of these challenges has the token from the authorization
@@ -869,6 +1534,63 @@ func addFQDNSet(ctx context.Context, db db.Inserter, idents identifier.ACMEIdent
 	})
 }
 
+// BackfillFQDNSet adds an fqdnSets row for the provided certificate if there
+// is not already one with its serial. It is used to repair certificates issued
+// before their fqdnSets rows were written, so it is safe to call more than once
+// for the same certificate. The certificate's NotBefore is used as its issuance
+// time.
+func BackfillFQDNSet(ctx context.Context, e db.Executor, cert *x509.Certificate) error {
+	serial := core.SerialToString(cert.SerialNumber)
+	var row struct {
+		Count int64
+	}
+	err := e.SelectOne(ctx, &row, "SELECT COUNT(*) AS count FROM fqdnSets WHERE serial = ?", serial)
+	if err != nil {
+		return err
+	}
+	if row.Count > 0 {
+		return nil
+	}
+
+	idents := identifier.FromCert(cert)
+	if len(idents) == 0 {
+		return fmt.Errorf("certificate %q has no identifiers", serial)
+	}
+	return addFQDNSet(ctx, e, idents, serial, cert.NotBefore, cert.NotAfter)
+}
+
+// FQDNSetIssuance is a single issuance of a certificate for an FQDN set.
+type FQDNSetIssuance struct {
+	Serial string    `db:"serial"`
+	Issued time.Time `db:"issued"`
+}
+
+// SelectFQDNSetHistory returns up to limit issuances for exactly the provided
+// set of identifiers, most recent first.
+func SelectFQDNSetHistory(ctx context.Context, s db.Selector, idents identifier.ACMEIdentifiers, limit int) ([]FQDNSetIssuance, error) {
+	if len(idents) == 0 {
+		return nil, errors.New("no identifiers provided")
+	}
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive, got %d", limit)
+	}
+	var history []FQDNSetIssuance
+	_, err := s.Select(
+		ctx,
+		&history,
+		`SELECT serial, issued FROM fqdnSets
+		WHERE setHash = ?
+		ORDER BY issued DESC
+		LIMIT ?`,
+		core.HashIdentifiers(idents),
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
 // addOrderFQDNSet creates a new OrderFQDNSet row using the provided
 // information. This function accepts a transaction so that the orderFqdnSet
 // addition can take place within the order addition transaction. The caller is
@@ -917,6 +1639,27 @@ func deleteOrderFQDNSet(
 	return nil
 }
 
+// DeleteExpiredOrderFQDNSets deletes up to limit orderFqdnSets rows whose
+// expires is before olderThan. Rows for orders which were abandoned before
+// finalization are never removed by deleteOrderFQDNSet, so this is intended to
+// be called in a loop until it returns zero. It returns the number of rows
+// deleted.
+func DeleteExpiredOrderFQDNSets(ctx context.Context, e db.Execer, olderThan time.Time, limit int) (int64, error) {
+	if limit <= 0 {
+		return 0, fmt.Errorf("limit must be positive, got %d", limit)
+	}
+	result, err := e.ExecContext(ctx, `
+		DELETE FROM orderFqdnSets
+		WHERE expires < ?
+		LIMIT ?`,
+		olderThan,
+		limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 func addIssuedNames(ctx context.Context, queryer db.Execer, cert *x509.Certificate, isRenewal bool) error {
 	if len(cert.DNSNames) == 0 && len(cert.IPAddresses) == 0 {
 		return berrors.InternalServerError("certificate has no DNSNames or IPAddresses")
@@ -966,6 +1709,30 @@ func EncodeIssuedName(name string) string {
 	return reverseFQDN(name)
 }
 
+// DecodeIssuedName returns the identifier represented by a reversedName value
+// stored in the issuedNames table. Values which parse as IP addresses are
+// returned as IP identifiers; all others are DNS identifiers whose labels are
+// restored to their usual order.
+func DecodeIssuedName(stored string) identifier.ACMEIdentifier {
+	ip, err := netip.ParseAddr(stored)
+	if err == nil {
+		return identifier.NewIP(ip)
+	}
+	return identifier.NewDNS(reverseFQDN(stored))
+}
+
+// FormatIssuedNameForDisplay returns a human-readable form of a reversedName
+// value stored in the issuedNames table, suitable for audit exports. DNS names
+// are restored to their usual label order, and IP addresses are returned as
+// stored.
+func FormatIssuedNameForDisplay(stored string) string {
+	ident := DecodeIssuedName(stored)
+	if ident.Type == identifier.TypeIP {
+		return stored
+	}
+	return ident.Value
+}
+
 // reverseFQDN reverses the elements of a dot-separated FQDN.
 //
 // If your string might be an IP address, use EncodeIssuedName() instead.
@@ -1142,6 +1909,195 @@ func getAuthorizationStatuses(ctx context.Context, s db.Selector, ids []int64) (
 	return validities, nil
 }
 
+// SelectEarliestAuthzExpiryForOrder returns the earliest expiry of the
+// authorizations belonging to the order with the given ID. An order is only
+// usable until its first authorization expires, so this is an upper bound on
+// the order's effective expiry.
+//
+// Orders store their authorization IDs in the serialized authzs column rather
+// than in a separate mapping table, so this reads the order before querying
+// authz2.
+func SelectEarliestAuthzExpiryForOrder(ctx context.Context, s db.Selector, orderID int64) (time.Time, error) {
+	var orders []struct {
+		Authzs []byte `db:"authzs"`
+	}
+	_, err := s.Select(ctx, &orders, "SELECT authzs FROM orders WHERE id = ?", orderID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(orders) == 0 {
+		return time.Time{}, berrors.NotFoundError("no order found for ID %d", orderID)
+	}
+
+	var authzs sapb.Authzs
+	err = proto.Unmarshal(orders[0].Authzs, &authzs)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(authzs.AuthzIDs) == 0 {
+		return time.Time{}, fmt.Errorf("order %d has no authorizations", orderID)
+	}
+
+	params := make([]any, len(authzs.AuthzIDs))
+	for i, id := range authzs.AuthzIDs {
+		params[i] = id
+	}
+	var earliest []struct {
+		Expires *time.Time `db:"expires"`
+	}
+	_, err = s.Select(
+		ctx,
+		&earliest,
+		fmt.Sprintf("SELECT MIN(expires) AS expires FROM authz2 WHERE id IN (%s)",
+			db.QuestionMarks(len(params))),
+		params...,
+	)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(earliest) == 0 || earliest[0].Expires == nil {
+		return time.Time{}, berrors.NotFoundError("no authorizations found for order %d", orderID)
+	}
+	return *earliest[0].Expires, nil
+}
+
+// SelectAttemptedChallengeTypes returns the distinct types of challenge which
+// the given account has attempted for the given identifier, across all of its
+// authorizations, sorted by name.
+func SelectAttemptedChallengeTypes(ctx context.Context, s db.Selector, regID int64, ident identifier.ACMEIdentifier) ([]string, error) {
+	identType, ok := identifierTypeToUint[string(ident.Type)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported identifier type %q", ident.Type)
+	}
+
+	var attempted []uint8
+	_, err := s.Select(
+		ctx,
+		&attempted,
+		`SELECT DISTINCT attempted FROM authz2
+		WHERE registrationID = ?
+		AND identifierType = ?
+		AND identifierValue = ?
+		AND attempted IS NOT NULL`,
+		regID,
+		identType,
+		ident.Value,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	challTypes := make([]string, 0, len(attempted))
+	for _, a := range attempted {
+		challType, ok := uintToChallType[a]
+		if !ok {
+			return nil, fmt.Errorf("unknown attempted challenge type %d", a)
+		}
+		challTypes = append(challTypes, challType)
+	}
+	slices.Sort(challTypes)
+	return challTypes, nil
+}
+
+// AuthorizationBelongsTo reports whether the authorization with the given ID
+// belongs to the given account. It returns a NotFound error if there is no
+// such authorization.
+func AuthorizationBelongsTo(ctx context.Context, s db.OneSelector, authzID int64, regID int64) (bool, error) {
+	var owner int64
+	err := s.SelectOne(
+		ctx,
+		&owner,
+		"SELECT registrationID FROM authz2 WHERE id = ? LIMIT 1",
+		authzID,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, berrors.NotFoundError("authorization with ID %d not found", authzID)
+		}
+		return false, err
+	}
+	return owner == regID, nil
+}
+
+// CountFailedAuthorizations returns the number of invalid authorizations for
+// the given identifier belonging to the given account which were attempted at
+// or after since.
+func CountFailedAuthorizations(ctx context.Context, s db.Selector, regID int64, ident identifier.ACMEIdentifier, since time.Time) (int64, error) {
+	identType, ok := identifierTypeToUint[string(ident.Type)]
+	if !ok {
+		return 0, fmt.Errorf("unsupported identifier type %q", ident.Type)
+	}
+
+	var counts []int64
+	_, err := s.Select(
+		ctx,
+		&counts,
+		`SELECT COUNT(*) FROM authz2
+		WHERE registrationID = ?
+		AND identifierType = ?
+		AND identifierValue = ?
+		AND status = ?
+		AND attemptedAt >= ?`,
+		regID,
+		identType,
+		ident.Value,
+		statusToUint[core.StatusInvalid],
+		since,
+	)
+	if err != nil {
+		return 0, err
+	}
+	if len(counts) != 1 {
+		return 0, fmt.Errorf("expected 1 row counting failed authorizations, got %d", len(counts))
+	}
+	return counts[0], nil
+}
+
+// SelectDistinctCertificateProfiles returns the names of all certificate
+// profiles which appear on at least one order, sorted by name. Orders without a
+// profile are ignored.
+func SelectDistinctCertificateProfiles(ctx context.Context, s db.Selector) ([]string, error) {
+	var profiles []string
+	_, err := s.Select(
+		ctx,
+		&profiles,
+		`SELECT DISTINCT certificateProfileName FROM orders
+		WHERE certificateProfileName IS NOT NULL
+		ORDER BY certificateProfileName`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// CheckSerialUniqueness returns the number of rows in the certificates and
+// precertificates tables with the provided serial. Each serial is expected to
+// appear at most once in each table; any other result indicates a data
+// integrity problem.
+func CheckSerialUniqueness(ctx context.Context, s db.Selector, serial string) (certCount int, precertCount int, err error) {
+	var counts []struct {
+		CertCount    int `db:"certCount"`
+		PrecertCount int `db:"precertCount"`
+	}
+	_, err = s.Select(
+		ctx,
+		&counts,
+		`SELECT
+			(SELECT COUNT(*) FROM certificates WHERE serial = ?) AS certCount,
+			(SELECT COUNT(*) FROM precertificates WHERE serial = ?) AS precertCount`,
+		serial,
+		serial,
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(counts) != 1 {
+		return 0, 0, fmt.Errorf("expected 1 row counting serial %q, got %d", serial, len(counts))
+	}
+	return counts[0].CertCount, counts[0].PrecertCount, nil
+}
+
 // crlShardModel represents one row in the crlShards table. The ThisUpdate and
 // NextUpdate fields are pointers because they are NULL-able columns.
 type crlShardModel struct {
@@ -1153,6 +2109,58 @@ type crlShardModel struct {
 	LeasedUntil time.Time  `db:"leasedUntil"`
 }
 
+// SelectStaleCRLShards returns the crlShards rows for the given issuer which
+// either have never been updated or whose nextUpdate is before staleBefore, and
+// whose lease expired before now. The shards are ordered by index.
+func SelectStaleCRLShards(ctx context.Context, s db.Selector, issuerID int64, staleBefore time.Time, now time.Time) ([]crlShardModel, error) {
+	var shards []crlShardModel
+	_, err := s.Select(
+		ctx,
+		&shards,
+		`SELECT id, issuerID, idx, thisUpdate, nextUpdate, leasedUntil
+			FROM crlShards
+			WHERE issuerID = ?
+			AND (nextUpdate IS NULL OR nextUpdate < ?)
+			AND leasedUntil < ?
+			ORDER BY idx`,
+		issuerID, staleBefore, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return shards, nil
+}
+
+// SetCRLShardUpdated records the thisUpdate and nextUpdate of a newly generated
+// CRL shard and releases that shard's lease. Like UpdateCRLShard, the lease is
+// released by setting leasedUntil to thisUpdate, which is necessarily in the
+// past. It returns a NotFound error if the shard does not exist.
+func SetCRLShardUpdated(ctx context.Context, e db.Execer, issuerID int64, idx int, thisUpdate, nextUpdate time.Time) error {
+	res, err := e.ExecContext(ctx,
+		`UPDATE crlShards
+			SET thisUpdate = ?, nextUpdate = ?, leasedUntil = ?
+			WHERE issuerID = ?
+			AND idx = ?
+			LIMIT 1`,
+		thisUpdate,
+		nextUpdate,
+		thisUpdate,
+		issuerID,
+		idx,
+	)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("confirming update of shard: %w", err)
+	}
+	if rowsAffected == 0 {
+		return berrors.NotFoundError("no CRL shard %d for issuer %d", idx, issuerID)
+	}
+	return nil
+}
+
 // revokedCertModel represents one row in the revokedCertificates table. It
 // contains all of the information necessary to populate a CRL entry or OCSP
 // response for the indicated certificate.
@@ -1166,6 +2174,23 @@ type revokedCertModel struct {
 	RevokedReason revocation.Reason `db:"revokedReason"`
 }
 
+// CRLShardForSerial returns the CRL shard index to which the certificate with
+// the given serial belongs, suitable for populating revokedCertModel.ShardIdx.
+// It uses the same assignment as the issuer (serial modulo numShards, plus one
+// because shard indices are 1-based), so the result is deterministic and
+// matches the shard in the certificate's CRLDistributionPoint.
+func CRLShardForSerial(serial string, numShards int) (int, error) {
+	if numShards <= 0 {
+		return 0, fmt.Errorf("number of CRL shards must be positive, got %d", numShards)
+	}
+	serialInt, err := core.StringToSerial(serial)
+	if err != nil {
+		return 0, err
+	}
+	shardZeroBased := new(big.Int).Mod(serialInt, big.NewInt(int64(numShards)))
+	return int(shardZeroBased.Int64()) + 1, nil
+}
+
 // replacementOrderModel represents one row in the replacementOrders table. It
 // contains all of the information necessary to link a renewal order to the
 // certificate it replaces.
@@ -1181,8 +2206,80 @@ type replacementOrderModel struct {
 	OrderExpires time.Time `db:"orderExpires"`
 	// Replaced is a boolean indicating whether the certificate has been
 	// replaced, i.e. whether the new order has been finalized. Once this is
-	// true, no new replacement orders can be accepted for the same Serial.
+	// true, no new replacement orders can be accepted for the same Serial,
+	// unless it is within the grace period following ReplacedAt.
 	Replaced bool `db:"replaced"`
+	// ReplacedAt is when the certificate was first replaced. It is a pointer
+	// because the column is NULL-able, and is only set if the
+	// StoreReplacementFinalizedTime feature is enabled.
+	ReplacedAt *time.Time `db:"replacedAt"`
+}
+
+// withinReplacementGrace returns true if a certificate which was replaced at
+// replacedAt may be replaced again at now, given the configured grace period.
+// Certificates whose replacement time was not recorded have no grace period.
+func withinReplacementGrace(replacedAt *time.Time, now time.Time, grace time.Duration) bool {
+	if replacedAt == nil || grace <= 0 {
+		return false
+	}
+	return replacedAt.After(now.Add(-grace))
+}
+
+// ValidateReplacesSerial returns an UnknownSerialError if replaces is not the
+// serial of a certificate (or precertificate) we have issued. Every such serial
+// has a certificateStatus row. If replaces is empty, it does nothing.
+func ValidateReplacesSerial(ctx context.Context, s db.OneSelector, replaces string) error {
+	if replaces == "" {
+		return nil
+	}
+	var one int
+	err := s.SelectOne(
+		ctx,
+		&one,
+		"SELECT 1 FROM certificateStatus WHERE serial = ? LIMIT 1",
+		replaces,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return berrors.UnknownSerialError()
+		}
+		return err
+	}
+	return nil
+}
+
+// SelectOrderByReplacesSerial selects the most recent order which replaces the
+// certificate with the given serial, as recorded in the replacementOrders table.
+// If there is no such order, a NotFound error is returned.
+func SelectOrderByReplacesSerial(ctx context.Context, s db.OneSelector, replaces string) (*corepb.Order, error) {
+	var orderID int64
+	err := s.SelectOne(
+		ctx,
+		&orderID,
+		"SELECT orderID FROM replacementOrders WHERE serial = ? ORDER BY id DESC LIMIT 1",
+		replaces,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, berrors.NotFoundError("no order replaces serial %q", replaces)
+		}
+		return nil, err
+	}
+
+	var om orderModel
+	err = s.SelectOne(
+		ctx,
+		&om,
+		"SELECT "+orderFields+" FROM orders WHERE id = ? LIMIT 1",
+		orderID,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, berrors.NotFoundError("no order replaces serial %q", replaces)
+		}
+		return nil, err
+	}
+	return modelToOrder(&om)
 }
 
 // addReplacementOrder inserts or updates the replacementOrders row matching the
@@ -1229,10 +2326,23 @@ func addReplacementOrder(ctx context.Context, db db.SelectExecer, serial string,
 }
 
 // setReplacementOrderFinalized sets the replaced flag for the replacementOrder
-// row matching the provided orderID to true. This function accepts a
-// transaction so that the update can take place within the finalization
-// transaction.
-func setReplacementOrderFinalized(ctx context.Context, db db.Execer, orderID int64) error {
+// row matching the provided orderID to true. If the
+// StoreReplacementFinalizedTime feature is enabled, it also records now as the
+// time of replacement, unless one was recorded by an earlier replacement. This
+// function accepts a transaction so that the update can take place within the
+// finalization transaction.
+func setReplacementOrderFinalized(ctx context.Context, db db.Execer, orderID int64, now time.Time) error {
+	if features.Get().StoreReplacementFinalizedTime {
+		_, err := db.ExecContext(ctx, `
+			UPDATE replacementOrders
+			SET replaced = true, replacedAt = COALESCE(replacedAt, ?)
+			WHERE orderID = ?
+			LIMIT 1`,
+			now,
+			orderID,
+		)
+		return err
+	}
 	_, err := db.ExecContext(ctx, `
 		UPDATE replacementOrders
 		SET replaced = true
@@ -1299,23 +2409,47 @@ func newPBFromIdentifierModels(ids []identifierModel) (*sapb.Identifiers, error)
 	return &sapb.Identifiers{Identifiers: pbs}, nil
 }
 
+// StorageFormForIdentifier returns the identifierType and identifierValue
+// column values used to store the given identifier in the authz2 and paused
+// tables. IP address values are normalized to their canonical string form. It
+// returns an error if the identifier type is unknown.
+func StorageFormForIdentifier(ident identifier.ACMEIdentifier) (uint8, string, error) {
+	idType, ok := identifierTypeToUint[string(ident.Type)]
+	if !ok {
+		return 0, "", fmt.Errorf("unsupported identifier type %q", ident.Type)
+	}
+	if ident.Type == identifier.TypeIP {
+		ip, err := netip.ParseAddr(ident.Value)
+		if err != nil {
+			return 0, "", fmt.Errorf("parsing IP identifier value %q: %w", ident.Value, err)
+		}
+		return idType, ip.String(), nil
+	}
+	return idType, ident.Value, nil
+}
+
 // buildIdentifierQueryConditions takes a slice of identifiers and returns a
 // string (conditions to use within the prepared statement) and a slice of anys
 // (arguments for the prepared statement), both to use within a WHERE clause for
-// queries against the authz2 table.
+// queries against the authz2 table. It returns an error if any identifier has
+// an unknown type.
 //
 // Although this function takes user-controlled input, it does not include any
 // of that input directly in the returned SQL string. The resulting string
 // contains only column names, boolean operators, and questionmark placeholders.
-func buildIdentifierQueryConditions(idents identifier.ACMEIdentifiers) (string, []any) {
+func buildIdentifierQueryConditions(idents identifier.ACMEIdentifiers) (string, []any, error) {
 	if len(idents) == 0 {
 		// No identifier values to check.
-		return "FALSE", []any{}
+		return "FALSE", []any{}, nil
 	}
 
-	identsByType := map[identifier.IdentifierType][]string{}
+	identsByType := map[uint8][]string{}
 	for _, id := range idents {
-		identsByType[id.Type] = append(identsByType[id.Type], id.Value)
+		idType, idValue, err := StorageFormForIdentifier(id)
+		if err != nil {
+			return "", nil, err
+		}
+		identsByType[idType] = append(identsByType[idType], idValue)
 	}
 
 	var conditions []string
@@ -1326,13 +2460,13 @@ func buildIdentifierQueryConditions(idents identifier.ACMEIdentifiers) (string,
 				db.QuestionMarks(len(idValues)),
 			),
 		)
-		args = append(args, identifierTypeToUint[string(idType)])
+		args = append(args, idType)
 		for _, idValue := range idValues {
 			args = append(args, idValue)
 		}
 	}
 
-	return strings.Join(conditions, " OR "), args
+	return strings.Join(conditions, " OR "), args, nil
 }
 
 // pausedModel represents a row in the paused table. It contains the
@@ -1382,3 +2516,153 @@ func newPBFromOverrideModel(m *overrideModel) *sapb.RateLimitOverride {
 		Burst:     m.Burst,
 	}
 }
+
+// UpsertRateLimitOverride inserts the provided rate limit override, or updates
+// the existing override with the same limitEnum and bucketKey, setting its
+// enabled state and updatedAt. Unlike AddRateLimitOverride, it does not check
+// whether the new override is lower than an existing one.
+func UpsertRateLimitOverride(ctx context.Context, e db.SelectExecer, pb *sapb.RateLimitOverride, enabled bool, now time.Time) error {
+	model := overrideModelForPB(pb, now, enabled)
+
+	var existing []int64
+	_, err := e.Select(ctx, &existing, `
+		SELECT limitEnum
+		FROM overrides
+		WHERE limitEnum = ? AND bucketKey = ?
+		LIMIT 1`,
+		model.LimitEnum,
+		model.BucketKey,
+	)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("checking for existing override: %w", err)
+	}
+
+	if len(existing) > 0 {
+		// Update existing overrides row.
+		_, err = e.ExecContext(ctx, `
+			UPDATE overrides
+			SET comment = ?, periodNS = ?, count = ?, burst = ?, updatedAt = ?, enabled = ?
+			WHERE limitEnum = ? AND bucketKey = ?`,
+			model.Comment, model.PeriodNS, model.Count, model.Burst, model.UpdatedAt, model.Enabled,
+			model.LimitEnum, model.BucketKey,
+		)
+		if err != nil {
+			return fmt.Errorf("updating override: %w", err)
+		}
+	} else {
+		// Insert new overrides row.
+		_, err = e.ExecContext(ctx, `
+			INSERT INTO overrides (`+overrideFields+`)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			model.LimitEnum, model.BucketKey, model.Comment, model.PeriodNS,
+			model.Count, model.Burst, model.UpdatedAt, model.Enabled,
+		)
+		if err != nil {
+			return fmt.Errorf("creating override: %w", err)
+		}
+	}
+	return nil
+}
+
+// SetRateLimitOverrideEnabled sets the enabled state of the rate limit override
+// with the given limitEnum and bucketKey, and sets its updatedAt to now. It
+// returns true if a row was changed, and false if no such override exists or
+// it was already in the requested state.
+func SetRateLimitOverrideEnabled(ctx context.Context, e db.Execer, limitEnum int64, bucketKey string, enabled bool, now time.Time) (bool, error) {
+	result, err := e.ExecContext(ctx, `
+		UPDATE overrides
+		SET enabled = ?, updatedAt = ?
+		WHERE limitEnum = ? AND bucketKey = ? AND enabled != ?`,
+		enabled, now,
+		limitEnum, bucketKey, enabled,
+	)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// overrideFields are the columns of the overrides table, in the order of the
+// fields of overrideModel.
+const overrideFields = "limitEnum, bucketKey, comment, periodNS, count, burst, updatedAt, enabled"
+
+// SelectRateLimitOverrides returns up to limit enabled rate limit overrides
+// which were last updated after sinceUpdated, in ascending order of their last
+// update. This allows a caller to incrementally sync overrides by passing the
+// most recent update time it has seen.
+func SelectRateLimitOverrides(ctx context.Context, s db.Selector, sinceUpdated time.Time, limit int) ([]*sapb.RateLimitOverride, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive, got %d", limit)
+	}
+	var models []overrideModel
+	_, err := s.Select(
+		ctx,
+		&models,
+		`SELECT `+overrideFields+` FROM overrides
+		WHERE enabled = true
+		AND updatedAt > ?
+		ORDER BY updatedAt, limitEnum, bucketKey
+		LIMIT ?`,
+		sinceUpdated,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	overrides := make([]*sapb.RateLimitOverride, 0, len(models))
+	for _, m := range models {
+		overrides = append(overrides, newPBFromOverrideModel(&m))
+	}
+	return overrides, nil
+}
+
+// DumpRateLimitOverrides writes all enabled rate limit overrides to w as CSV,
+// one row per override, including when each was last updated. Rows are sorted
+// by limitEnum and then bucketKey, unless byUpdatedAt is true, in which case
+// the most recently updated overrides come first. This supports auditing
+// recent changes to the overrides table.
+func DumpRateLimitOverrides(ctx context.Context, s db.Selector, w io.Writer, byUpdatedAt bool) error {
+	orderBy := "limitEnum, bucketKey"
+	if byUpdatedAt {
+		orderBy = "updatedAt DESC, limitEnum, bucketKey"
+	}
+
+	var models []overrideModel
+	_, err := s.Select(
+		ctx,
+		&models,
+		`SELECT `+overrideFields+` FROM overrides
+		WHERE enabled = true
+		ORDER BY `+orderBy,
+	)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	err = cw.Write([]string{"limitEnum", "bucketKey", "count", "burst", "period", "comment", "updatedAt"})
+	if err != nil {
+		return err
+	}
+	for _, m := range models {
+		err := cw.Write([]string{
+			strconv.FormatInt(m.LimitEnum, 10),
+			m.BucketKey,
+			strconv.FormatInt(m.Count, 10),
+			strconv.FormatInt(m.Burst, 10),
+			time.Duration(m.PeriodNS).String(),
+			m.Comment,
+			m.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
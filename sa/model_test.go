@@ -5,25 +5,35 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
 	"net/netip"
+	"os"
 	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-jose/go-jose/v4"
 	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/letsencrypt/boulder/db"
+	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/goodkey"
 	"github.com/letsencrypt/boulder/grpc"
 	"github.com/letsencrypt/boulder/identifier"
 	"github.com/letsencrypt/boulder/probs"
+	"github.com/letsencrypt/boulder/revocation"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
 	"github.com/letsencrypt/boulder/test/vars"
 
@@ -224,6 +234,186 @@ func TestAuthzModel(t *testing.T) {
 	}
 }
 
+func TestHydrateAuthz(t *testing.T) {
+	t.Parallel()
+
+	validated := clock.NewFake().Now()
+	newAuthzPB := func() *corepb.Authorization {
+		return &corepb.Authorization{
+			Id:             1,
+			Identifier:     identifier.NewDNS("example.com").ToProto(),
+			RegistrationID: 1,
+			Status:         string(core.StatusValid),
+			Expires:        timestamppb.New(validated.Add(24 * time.Hour)),
+			Challenges: []*corepb.Challenge{
+				{
+					Type:      string(core.ChallengeTypeHTTP01),
+					Status:    string(core.StatusValid),
+					Token:     "MTIz",
+					Validated: timestamppb.New(validated),
+					Validationrecords: []*corepb.ValidationRecord{
+						{
+							AddressUsed:       []byte("1.2.3.4"),
+							Url:               "https://example.com",
+							Hostname:          "example.com",
+							Port:              "443",
+							AddressesResolved: [][]byte{{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 2, 3, 4}},
+							AddressesTried:    [][]byte{{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 2, 3, 4}},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	model, err := authzPBToModel(newAuthzPB())
+	test.AssertNotError(t, err, "authzPBToModel failed")
+
+	// Round-trip the row through JSON, as tooling reading raw rows would.
+	raw, err := json.Marshal(model)
+	test.AssertNotError(t, err, "marshaling authzModel")
+	var am authzModel
+	err = json.Unmarshal(raw, &am)
+	test.AssertNotError(t, err, "unmarshaling authzModel")
+
+	authzPB, err := HydrateAuthz(am)
+	test.AssertNotError(t, err, "HydrateAuthz failed")
+	test.AssertDeepEquals(t, authzPB, newAuthzPB())
+
+	am.IdentifierType = 255
+	_, err = HydrateAuthz(am)
+	test.AssertError(t, err, "HydrateAuthz should fail for an unknown identifier type")
+}
+
+func TestModelToAuthzPBAttemptedConsistency(t *testing.T) {
+	t.Parallel()
+
+	attempted := challTypeToUint[string(core.ChallengeTypeDNS01)]
+	am := authzModel{
+		ID:               1,
+		IdentifierType:   identifierTypeToUint[string(identifier.TypeDNS)],
+		IdentifierValue:  "example.com",
+		RegistrationID:   1,
+		Status:           statusToUint[core.StatusValid],
+		Expires:          time.Now().Add(time.Hour),
+		Challenges:       1<<challTypeToUint[string(core.ChallengeTypeHTTP01)] | 1<<attempted,
+		Attempted:        &attempted,
+		Token:            []byte("token"),
+		ValidationRecord: []byte("[]"),
+	}
+
+	// A model whose attempted challenge is in its bitmap is consistent.
+	pb, err := modelToAuthzPB(am)
+	test.AssertNotError(t, err, "consistent model should convert")
+	test.AssertEquals(t, len(pb.Challenges), 1)
+	test.AssertEquals(t, pb.Challenges[0].Type, string(core.ChallengeTypeDNS01))
+
+	// A model whose attempted challenge is missing from its bitmap is corrupt.
+	am.Challenges = 1 << challTypeToUint[string(core.ChallengeTypeHTTP01)]
+	_, err = modelToAuthzPB(am)
+	test.AssertError(t, err, "corrupt model should not convert")
+	test.AssertContains(t, err.Error(), "not in its challenges bitmap")
+}
+
+func TestNewAuthzReqToModelToken(t *testing.T) {
+	t.Parallel()
+
+	newReq := func(token string) *sapb.NewAuthzRequest {
+		return &sapb.NewAuthzRequest{
+			Identifier:     identifier.NewDNS("example.com").ToProto(),
+			RegistrationID: 1,
+			Expires:        timestamppb.New(time.Now().Add(time.Hour)),
+			ChallengeTypes: []string{string(core.ChallengeTypeHTTP01)},
+			Token:          token,
+		}
+	}
+
+	token := core.NewToken()
+	am, err := newAuthzReqToModel(newReq(token), "", nil)
+	test.AssertNotError(t, err, "correctly-encoded token should be accepted")
+	test.AssertEquals(t, base64.RawURLEncoding.EncodeToString(am.Token), token)
+
+	// A token which was encoded a second time by a buggy writer should be
+	// rejected rather than stored as garbage.
+	doubleEncoded := base64.RawURLEncoding.EncodeToString([]byte(token))
+	_, err = newAuthzReqToModel(newReq(doubleEncoded), "", nil)
+	test.AssertError(t, err, "double-encoded token should be rejected")
+
+	// A token with non-zero trailing bits decodes, but does not round trip.
+	_, err = decodeAuthzToken("MTJ")
+	test.AssertError(t, err, "non-canonical token should be rejected")
+	_, err = decodeAuthzToken("MTI")
+	test.AssertNotError(t, err, "canonical token should be accepted")
+}
+
+func TestNewAuthzReqToModelChallengeTypes(t *testing.T) {
+	t.Parallel()
+
+	challTypes := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test"}, []string{"type"})
+	newReq := func(types ...core.AcmeChallenge) *sapb.NewAuthzRequest {
+		req := &sapb.NewAuthzRequest{
+			Identifier:     identifier.NewDNS("example.com").ToProto(),
+			RegistrationID: 1,
+			Expires:        timestamppb.New(time.Now().Add(time.Hour)),
+			Token:          core.NewToken(),
+		}
+		for _, t := range types {
+			req.ChallengeTypes = append(req.ChallengeTypes, string(t))
+		}
+		return req
+	}
+
+	_, err := newAuthzReqToModel(newReq(core.ChallengeTypeHTTP01, core.ChallengeTypeDNS01), "", challTypes)
+	test.AssertNotError(t, err, "newAuthzReqToModel failed")
+	_, err = newAuthzReqToModel(newReq(core.ChallengeTypeHTTP01, core.ChallengeTypeTLSALPN01), "", challTypes)
+	test.AssertNotError(t, err, "newAuthzReqToModel failed")
+
+	test.AssertMetricWithLabelsEquals(t, challTypes, prometheus.Labels{"type": "http-01"}, 2)
+	test.AssertMetricWithLabelsEquals(t, challTypes, prometheus.Labels{"type": "dns-01"}, 1)
+	test.AssertMetricWithLabelsEquals(t, challTypes, prometheus.Labels{"type": "tls-alpn-01"}, 1)
+	test.AssertMetricWithLabelsEquals(t, challTypes, prometheus.Labels{"type": "dns-account-01"}, 0)
+
+	// A rejected request is not counted.
+	_, err = newAuthzReqToModel(&sapb.NewAuthzRequest{
+		Identifier:     identifier.NewDNS("example.com").ToProto(),
+		RegistrationID: 1,
+		Expires:        timestamppb.New(time.Now().Add(time.Hour)),
+		ChallengeTypes: []string{string(core.ChallengeTypeHTTP01)},
+		Token:          "bad",
+	}, "", challTypes)
+	test.AssertError(t, err, "bad token should be rejected")
+	test.AssertMetricWithLabelsEquals(t, challTypes, prometheus.Labels{"type": "http-01"}, 2)
+}
+
+func TestValidateChallengesForIdentifierType(t *testing.T) {
+	t.Parallel()
+
+	bit := func(challType core.AcmeChallenge) uint8 {
+		return 1 << challTypeToUint[string(challType)]
+	}
+	ip := identifierTypeToUint[string(identifier.TypeIP)]
+	dns := identifierTypeToUint[string(identifier.TypeDNS)]
+
+	err := validateChallengesForIdentifierType(ip, bit(core.ChallengeTypeHTTP01)|bit(core.ChallengeTypeTLSALPN01))
+	test.AssertNotError(t, err, "IP authz with HTTP-01 and TLS-ALPN-01 should be valid")
+
+	err = validateChallengesForIdentifierType(ip, bit(core.ChallengeTypeHTTP01)|bit(core.ChallengeTypeDNS01))
+	test.AssertError(t, err, "IP authz with DNS-01 should be invalid")
+	test.AssertContains(t, err.Error(), "dns-01")
+
+	err = validateChallengesForIdentifierType(dns, bit(core.ChallengeTypeHTTP01)|bit(core.ChallengeTypeDNS01))
+	test.AssertNotError(t, err, "DNS authz with DNS-01 should be valid")
+
+	_, err = newAuthzReqToModel(&sapb.NewAuthzRequest{
+		Identifier:     identifier.NewIP(netip.MustParseAddr("64.112.117.1")).ToProto(),
+		RegistrationID: 1,
+		Expires:        timestamppb.New(time.Now().Add(time.Hour)),
+		ChallengeTypes: []string{string(core.ChallengeTypeHTTP01), string(core.ChallengeTypeDNS01)},
+		Token:          core.NewToken(),
+	}, "", nil)
+	test.AssertError(t, err, "newAuthzReqToModel should reject an IP authz with DNS-01")
+}
+
 // TestModelToOrderBADJSON tests that converting an order model with an invalid
 // validation error JSON field to an Order produces the expected bad JSON error.
 func TestModelToOrderBadJSON(t *testing.T) {
@@ -467,6 +657,62 @@ func TestAddReplacementOrder(t *testing.T) {
 	test.AssertEquals(t, nextOrderExpires, replacementRow.OrderExpires)
 }
 
+func TestSelectOrderByReplacesSerial(t *testing.T) {
+	sa, fc := initSA(t)
+
+	reg := createWorkingRegistration(t, sa)
+	oldCertSerial := "1234567890"
+	replaces := "ARICertID"
+	expires := fc.Now().Add(24 * time.Hour)
+
+	insert := func() int64 {
+		t.Helper()
+		om := orderModel{
+			RegistrationID: reg.Id,
+			Expires:        expires,
+			Created:        fc.Now(),
+			Replaces:       &replaces,
+		}
+		err := sa.dbMap.Insert(ctx, &om)
+		test.AssertNotError(t, err, "inserting order")
+		err = addReplacementOrder(ctx, sa.dbMap, oldCertSerial, om.ID, expires)
+		test.AssertNotError(t, err, "addReplacementOrder failed")
+		return om.ID
+	}
+
+	_, err := SelectOrderByReplacesSerial(ctx, sa.dbMap, oldCertSerial)
+	test.AssertErrorIs(t, err, berrors.NotFound)
+
+	firstID := insert()
+	order, err := SelectOrderByReplacesSerial(ctx, sa.dbMap, oldCertSerial)
+	test.AssertNotError(t, err, "SelectOrderByReplacesSerial failed")
+	test.AssertEquals(t, order.Id, firstID)
+	test.AssertEquals(t, order.RegistrationID, reg.Id)
+	test.AssertEquals(t, order.Replaces, replaces)
+
+	// A later replacement order for the same serial is returned instead.
+	secondID := insert()
+	order, err = SelectOrderByReplacesSerial(ctx, sa.dbMap, oldCertSerial)
+	test.AssertNotError(t, err, "SelectOrderByReplacesSerial failed")
+	test.AssertEquals(t, order.Id, secondID)
+
+	_, err = SelectOrderByReplacesSerial(ctx, sa.dbMap, "9876543210")
+	test.AssertErrorIs(t, err, berrors.NotFound)
+}
+
+func TestWithinReplacementGrace(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	recent := now.Add(-time.Minute)
+	old := now.Add(-2 * time.Hour)
+
+	test.Assert(t, withinReplacementGrace(&recent, now, time.Hour), "recent replacement should be within grace")
+	test.Assert(t, !withinReplacementGrace(&old, now, time.Hour), "old replacement should be past grace")
+	test.Assert(t, !withinReplacementGrace(&recent, now, 0), "no grace period should be allowed when disabled")
+	test.Assert(t, !withinReplacementGrace(nil, now, time.Hour), "unrecorded replacement should have no grace period")
+}
+
 func TestSetReplacementOrderFinalized(t *testing.T) {
 	sa, _ := initSA(t)
 
@@ -475,7 +721,7 @@ func TestSetReplacementOrderFinalized(t *testing.T) {
 	orderExpires := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
 
 	// Mark a non-existent certificate as finalized/replaced.
-	err := setReplacementOrderFinalized(ctx, sa.dbMap, orderId)
+	err := setReplacementOrderFinalized(ctx, sa.dbMap, orderId, time.Now())
 	test.AssertNotError(t, err, "setReplacementOrderFinalized failed")
 
 	// Ensure no replacement order was added for some reason.
@@ -493,7 +739,7 @@ func TestSetReplacementOrderFinalized(t *testing.T) {
 	test.AssertNotError(t, err, "addReplacementOrder failed")
 
 	// Mark the certificate as finalized/replaced.
-	err = setReplacementOrderFinalized(ctx, sa.dbMap, orderId)
+	err = setReplacementOrderFinalized(ctx, sa.dbMap, orderId, time.Now())
 	test.AssertNotError(t, err, "setReplacementOrderFinalized failed")
 
 	// Fetch the replacement order so we can ensure it was finalized.
@@ -506,3 +752,1103 @@ func TestSetReplacementOrderFinalized(t *testing.T) {
 	test.AssertNotError(t, err, "SELECT from replacementOrders failed")
 	test.Assert(t, replacementRow.Replaced, "replacement order should be marked as finalized")
 }
+
+// insertCertificateStatus inserts a minimal certificateStatus row for the given
+// serial, with the given status and notAfter.
+func insertCertificateStatus(t *testing.T, dbMap *db.WrappedMap, serial string, status core.OCSPStatus, notAfter time.Time) {
+	t.Helper()
+	_, err := dbMap.ExecContext(ctx,
+		`INSERT INTO certificateStatus
+			(serial, status, ocspLastUpdated, revokedDate, revokedReason, lastExpirationNagSent, notAfter, isExpired, issuerID)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		serial, string(status), time.Time{}, time.Time{}, 0, time.Time{}, notAfter, false, 1,
+	)
+	if err != nil {
+		t.Fatalf("inserting certificateStatus row for serial %q: %s", serial, err)
+	}
+}
+
+func TestValidateReplacesSerial(t *testing.T) {
+	sa, fc := initSA(t)
+
+	serial := "000000000000000000000000000000000001"
+	insertCertificateStatus(t, sa.dbMap, serial, core.OCSPStatusGood, fc.Now().Add(time.Hour))
+
+	err := ValidateReplacesSerial(ctx, sa.dbMap, serial)
+	test.AssertNotError(t, err, "existing serial should be valid")
+
+	err = ValidateReplacesSerial(ctx, sa.dbMap, "000000000000000000000000000000000002")
+	test.AssertErrorIs(t, err, berrors.UnknownSerial)
+
+	err = ValidateReplacesSerial(ctx, sa.dbMap, "")
+	test.AssertNotError(t, err, "empty replaces should be a no-op")
+}
+
+func TestSetRevocationComment(t *testing.T) {
+	if os.Getenv("BOULDER_CONFIG_DIR") != "test/config-next" {
+		t.Skip("TestSetRevocationComment requires config-next")
+	}
+
+	sa, fc := initSA(t)
+
+	serial := "000000000000000000000000000000000001"
+	insertCertificateStatus(t, sa.dbMap, serial, core.OCSPStatusRevoked, fc.Now().Add(time.Hour))
+
+	status, err := SelectRevocationStatus(ctx, sa.dbMap, serial)
+	test.AssertNotError(t, err, "SelectRevocationStatus failed")
+	test.AssertBoxedNil(t, status.RevokedComment, "revokedComment should be NULL")
+
+	err = SetRevocationComment(ctx, sa.dbMap, serial, "key found in public repo")
+	test.AssertNotError(t, err, "SetRevocationComment failed")
+
+	status, err = SelectRevocationStatus(ctx, sa.dbMap, serial)
+	test.AssertNotError(t, err, "SelectRevocationStatus failed")
+	test.AssertEquals(t, status.Status, core.OCSPStatusRevoked)
+	test.AssertEquals(t, *status.RevokedComment, "key found in public repo")
+
+	// A comment exceeding the maximum length should be rejected.
+	err = SetRevocationComment(ctx, sa.dbMap, serial, strings.Repeat("a", maxRevokedCommentLength+1))
+	test.AssertErrorIs(t, err, berrors.Malformed)
+
+	// The previous comment should be untouched.
+	status, err = SelectRevocationStatus(ctx, sa.dbMap, serial)
+	test.AssertNotError(t, err, "SelectRevocationStatus failed")
+	test.AssertEquals(t, *status.RevokedComment, "key found in public repo")
+
+	// Setting a comment for an unknown serial should fail.
+	err = SetRevocationComment(ctx, sa.dbMap, "unknown", "comment")
+	test.AssertErrorIs(t, err, berrors.NotFound)
+}
+
+func TestSelectCertStatusesExpiringBetween(t *testing.T) {
+	sa, fc := initSA(t)
+
+	start := fc.Now().Add(24 * time.Hour)
+	end := start.Add(24 * time.Hour)
+
+	// Rows just outside the window, on both sides, should never be returned.
+	insertCertificateStatus(t, sa.dbMap, "before", core.OCSPStatusGood, start.Add(-time.Second))
+	insertCertificateStatus(t, sa.dbMap, "after", core.OCSPStatusGood, end.Add(time.Second))
+
+	// Rows exactly on the boundaries, and in between, should be returned.
+	insertCertificateStatus(t, sa.dbMap, "start", core.OCSPStatusGood, start)
+	insertCertificateStatus(t, sa.dbMap, "middle", core.OCSPStatusRevoked, start.Add(12*time.Hour))
+	insertCertificateStatus(t, sa.dbMap, "end", core.OCSPStatusGood, end)
+
+	// Page through the window two rows at a time.
+	var serials []string
+	var sinceID int64
+	var pages int
+	for {
+		statuses, highestID, err := SelectCertStatusesExpiringBetween(ctx, sa.dbMap, start, end, sinceID, 2)
+		test.AssertNotError(t, err, "SelectCertStatusesExpiringBetween failed")
+		if len(statuses) == 0 {
+			test.AssertEquals(t, highestID, int64(0))
+			break
+		}
+		test.Assert(t, len(statuses) <= 2, "page exceeded limit")
+		test.Assert(t, highestID > sinceID, "highest ID did not advance")
+		for _, status := range statuses {
+			serials = append(serials, status.Serial)
+		}
+		sinceID = highestID
+		pages++
+	}
+	test.AssertEquals(t, pages, 2)
+	test.AssertDeepEquals(t, serials, []string{"start", "middle", "end"})
+}
+
+func TestSelectCertificateStatusesRecomputeExpiry(t *testing.T) {
+	sa, fc := initSA(t)
+
+	now := fc.Now()
+	// Both rows are stored with isExpired false.
+	insertCertificateStatus(t, sa.dbMap, "expired", core.OCSPStatusGood, now.Add(-time.Hour))
+	insertCertificateStatus(t, sa.dbMap, "current", core.OCSPStatusGood, now.Add(time.Hour))
+
+	statuses, err := SelectCertificateStatusesRecomputeExpiry(ctx, sa.dbMap, []string{"expired", "current", "missing"}, now)
+	test.AssertNotError(t, err, "SelectCertificateStatusesRecomputeExpiry failed")
+	test.AssertEquals(t, len(statuses), 2)
+	for _, status := range statuses {
+		switch status.Serial {
+		case "expired":
+			test.Assert(t, status.IsExpired, "certificate past notAfter should be reported expired")
+		case "current":
+			test.Assert(t, !status.IsExpired, "certificate before notAfter should not be reported expired")
+		default:
+			t.Errorf("unexpected serial %q", status.Serial)
+		}
+	}
+}
+
+func TestSelectCertificatesByRegistrationID(t *testing.T) {
+	sa, fc := initSA(t)
+
+	// Interleave certificates for two accounts.
+	var want []string
+	for i := range 10 {
+		regID := int64(1 + i%2)
+		serial := fmt.Sprintf("%036x", i)
+		err := sa.dbMap.Insert(ctx, &core.Certificate{
+			RegistrationID: regID,
+			Serial:         serial,
+			Digest:         "digest",
+			DER:            []byte{byte(i)},
+			Issued:         fc.Now(),
+			Expires:        fc.Now().Add(time.Hour),
+		})
+		test.AssertNotError(t, err, "inserting certificate")
+		if regID == 1 {
+			want = append(want, serial)
+		}
+	}
+
+	_, _, err := SelectCertificatesByRegistrationID(ctx, sa.dbMap, 1, 0, 0)
+	test.AssertError(t, err, "expected error for zero limit")
+
+	var got []string
+	var pages int
+	var sinceID int64
+	for {
+		certs, highestID, err := SelectCertificatesByRegistrationID(ctx, sa.dbMap, 1, sinceID, 2)
+		test.AssertNotError(t, err, "SelectCertificatesByRegistrationID failed")
+		if len(certs) == 0 {
+			test.Assert(t, certs == nil, "empty page should be a nil slice")
+			test.AssertEquals(t, highestID, int64(0))
+			break
+		}
+		test.Assert(t, highestID > sinceID, "highest ID should increase with each page")
+		for _, cert := range certs {
+			test.AssertEquals(t, cert.RegistrationID, int64(1))
+			got = append(got, cert.Serial)
+		}
+		sinceID = highestID
+		pages++
+	}
+	test.AssertEquals(t, pages, 3)
+	test.AssertDeepEquals(t, got, want)
+
+	certs, highestID, err := SelectCertificatesByRegistrationID(ctx, sa.dbMap, 3, 0, 10)
+	test.AssertNotError(t, err, "SelectCertificatesByRegistrationID failed")
+	test.Assert(t, certs == nil, "expected no certificates for unknown account")
+	test.AssertEquals(t, highestID, int64(0))
+}
+
+func TestSelectExpiringCertificates(t *testing.T) {
+	sa, fc := initSA(t)
+
+	now := fc.Now()
+	windowStart := now.Add(24 * time.Hour)
+	windowEnd := now.Add(48 * time.Hour)
+
+	addCert := func(serial string, expires time.Time) {
+		t.Helper()
+		err := sa.dbMap.Insert(ctx, &core.Certificate{
+			RegistrationID: 1,
+			Serial:         serial,
+			Digest:         "digest",
+			DER:            []byte{1},
+			Issued:         now,
+			Expires:        expires,
+		})
+		test.AssertNotError(t, err, "inserting certificate")
+	}
+
+	const (
+		inWindow      = "00000000000000000000000000000000000a"
+		noStatus      = "00000000000000000000000000000000000b"
+		markedExpired = "00000000000000000000000000000000000c"
+		tooLate       = "00000000000000000000000000000000000d"
+		alsoInWindow  = "00000000000000000000000000000000000e"
+	)
+	addCert(inWindow, windowStart.Add(time.Hour))
+	insertCertificateStatus(t, sa.dbMap, inWindow, core.OCSPStatusGood, windowStart.Add(time.Hour))
+	addCert(noStatus, windowStart.Add(time.Hour))
+	addCert(markedExpired, windowStart.Add(time.Hour))
+	insertCertificateStatus(t, sa.dbMap, markedExpired, core.OCSPStatusGood, windowStart.Add(time.Hour))
+	_, err := sa.dbMap.ExecContext(ctx, "UPDATE certificateStatus SET isExpired = true WHERE serial = ?", markedExpired)
+	test.AssertNotError(t, err, "marking certificate expired")
+	addCert(tooLate, windowEnd.Add(time.Hour))
+	insertCertificateStatus(t, sa.dbMap, tooLate, core.OCSPStatusGood, windowEnd.Add(time.Hour))
+	addCert(alsoInWindow, windowEnd.Add(-time.Hour))
+	insertCertificateStatus(t, sa.dbMap, alsoInWindow, core.OCSPStatusGood, windowEnd.Add(-time.Hour))
+
+	// The first page includes the certificate without a status row, which is
+	// skipped.
+	certs, highestID, err := SelectExpiringCertificates(ctx, sa.dbMap, windowStart, windowEnd, 0, 2)
+	test.AssertNotError(t, err, "SelectExpiringCertificates failed")
+	test.AssertEquals(t, len(certs), 1)
+	test.AssertEquals(t, certs[0].Serial, inWindow)
+	test.Assert(t, highestID > 0, "highest ID should include the skipped certificate")
+
+	certs, highestID, err = SelectExpiringCertificates(ctx, sa.dbMap, windowStart, windowEnd, highestID, 2)
+	test.AssertNotError(t, err, "SelectExpiringCertificates failed")
+	test.AssertEquals(t, len(certs), 1)
+	test.AssertEquals(t, certs[0].Serial, alsoInWindow)
+
+	certs, highestID, err = SelectExpiringCertificates(ctx, sa.dbMap, windowStart, windowEnd, highestID, 2)
+	test.AssertNotError(t, err, "SelectExpiringCertificates failed")
+	test.AssertEquals(t, len(certs), 0)
+	test.AssertEquals(t, highestID, int64(0))
+}
+
+func TestStreamCertificates(t *testing.T) {
+	sa, fc := initSA(t)
+
+	const numCerts = 250
+	for i := range numCerts {
+		err := sa.dbMap.Insert(ctx, &core.Certificate{
+			RegistrationID: 1,
+			Serial:         fmt.Sprintf("%036x", i),
+			Digest:         "digest",
+			DER:            []byte{byte(i)},
+			Issued:         fc.Now(),
+			Expires:        fc.Now().Add(time.Hour),
+		})
+		test.AssertNotError(t, err, "inserting certificate")
+	}
+
+	var serials []string
+	err := StreamCertificates(ctx, sa.dbMap, 0, 40, func(cert *corepb.Certificate) error {
+		serials = append(serials, cert.Serial)
+		return nil
+	})
+	test.AssertNotError(t, err, "StreamCertificates failed")
+	test.AssertEquals(t, len(serials), numCerts)
+	for i, serial := range serials {
+		test.AssertEquals(t, serial, fmt.Sprintf("%036x", i))
+	}
+
+	// An error from the callback should stop the stream and be returned.
+	errStop := errors.New("stop")
+	var seen int
+	err = StreamCertificates(ctx, sa.dbMap, 0, 40, func(cert *corepb.Certificate) error {
+		seen++
+		if seen == 50 {
+			return errStop
+		}
+		return nil
+	})
+	test.AssertErrorIs(t, err, errStop)
+	test.AssertEquals(t, seen, 50)
+
+	// A canceled context should stop the stream.
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	err = StreamCertificates(canceledCtx, sa.dbMap, 0, 40, func(cert *corepb.Certificate) error {
+		t.Fatal("callback should not be called with a canceled context")
+		return nil
+	})
+	test.AssertErrorIs(t, err, context.Canceled)
+}
+
+func TestStreamRevokedCerts(t *testing.T) {
+	sa, fc := initSA(t)
+
+	insert := func(serial string, issuerID, shardIdx int64) {
+		t.Helper()
+		err := sa.dbMap.Insert(ctx, &revokedCertModel{
+			IssuerID:      issuerID,
+			Serial:        serial,
+			NotAfterHour:  fc.Now().Add(time.Hour).Truncate(time.Hour),
+			ShardIdx:      shardIdx,
+			RevokedDate:   fc.Now(),
+			RevokedReason: revocation.KeyCompromise,
+		})
+		test.AssertNotError(t, err, "inserting revoked certificate")
+	}
+	for i := range 5 {
+		insert(fmt.Sprintf("%036x", i), 1, 1)
+	}
+	// Entries for other shards and issuers should not be visited.
+	insert("0000000000000000000000000000000000aa", 1, 2)
+	insert("0000000000000000000000000000000000bb", 2, 1)
+
+	seen := make(map[string]int)
+	err := StreamRevokedCerts(ctx, sa.dbMap, 1, 1, func(entry crlEntryModel) error {
+		seen[entry.Serial]++
+		test.AssertEquals(t, entry.Status, core.OCSPStatusRevoked)
+		test.AssertEquals(t, entry.RevokedReason, revocation.KeyCompromise)
+		return nil
+	})
+	test.AssertNotError(t, err, "StreamRevokedCerts failed")
+	test.AssertEquals(t, len(seen), 5)
+	for i := range 5 {
+		test.AssertEquals(t, seen[fmt.Sprintf("%036x", i)], 1)
+	}
+
+	// An error from the callback should stop the stream and be returned.
+	errStop := errors.New("stop")
+	var visited int
+	err = StreamRevokedCerts(ctx, sa.dbMap, 1, 1, func(entry crlEntryModel) error {
+		visited++
+		if visited == 2 {
+			return errStop
+		}
+		return nil
+	})
+	test.AssertErrorIs(t, err, errStop)
+	test.AssertEquals(t, visited, 2)
+
+	// A canceled context should stop the stream.
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	err = StreamRevokedCerts(canceledCtx, sa.dbMap, 1, 1, func(entry crlEntryModel) error {
+		t.Fatal("callback should not be called with a canceled context")
+		return nil
+	})
+	test.AssertErrorIs(t, err, context.Canceled)
+}
+
+func TestStorageFormForIdentifier(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		ident     identifier.ACMEIdentifier
+		wantType  uint8
+		wantValue string
+		wantErr   bool
+	}{
+		{
+			name:      "DNS",
+			ident:     identifier.NewDNS("example.com"),
+			wantType:  0,
+			wantValue: "example.com",
+		},
+		{
+			name:      "IPv4",
+			ident:     identifier.NewIP(netip.MustParseAddr("1.2.3.4")),
+			wantType:  1,
+			wantValue: "1.2.3.4",
+		},
+		{
+			name:      "IPv6 is normalized",
+			ident:     identifier.ACMEIdentifier{Type: identifier.TypeIP, Value: "2602:0080:1234:0000:0000:0000:0000:0001"},
+			wantType:  1,
+			wantValue: "2602:80:1234::1",
+		},
+		{
+			name:    "Malformed IP",
+			ident:   identifier.ACMEIdentifier{Type: identifier.TypeIP, Value: "not-an-ip"},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown type",
+			ident:   identifier.ACMEIdentifier{Type: "email", Value: "admin@example.com"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			idType, idValue, err := StorageFormForIdentifier(tc.ident)
+			if tc.wantErr {
+				test.AssertError(t, err, "expected an error")
+				return
+			}
+			test.AssertNotError(t, err, "unexpected error")
+			test.AssertEquals(t, idType, tc.wantType)
+			test.AssertEquals(t, idValue, tc.wantValue)
+		})
+	}
+}
+
+func TestCRLShardForSerial(t *testing.T) {
+	t.Parallel()
+
+	_, err := CRLShardForSerial("0000000000000000000000000000000000ff", 0)
+	test.AssertError(t, err, "expected error for zero shards")
+	_, err = CRLShardForSerial("0000000000000000000000000000000000ff", -1)
+	test.AssertError(t, err, "expected error for negative shards")
+	_, err = CRLShardForSerial("not-a-serial", 128)
+	test.AssertError(t, err, "expected error for malformed serial")
+
+	// 0xff % 128 = 127, plus one because shards are 1-based.
+	shard, err := CRLShardForSerial("0000000000000000000000000000000000ff", 128)
+	test.AssertNotError(t, err, "CRLShardForSerial failed")
+	test.AssertEquals(t, shard, 128)
+
+	// Every serial lands in the same shard on every call, and serials are
+	// spread roughly evenly across all shards.
+	const numShards = 16
+	const numSerials = 16000
+	counts := make(map[int]int)
+	for i := range numSerials {
+		serial := core.SerialToString(big.NewInt(int64(i) * 7919))
+		shard, err := CRLShardForSerial(serial, numShards)
+		test.AssertNotError(t, err, "CRLShardForSerial failed")
+		again, err := CRLShardForSerial(serial, numShards)
+		test.AssertNotError(t, err, "CRLShardForSerial failed")
+		test.AssertEquals(t, shard, again)
+		test.Assert(t, shard >= 1 && shard <= numShards, fmt.Sprintf("shard %d out of range", shard))
+		counts[shard]++
+	}
+	test.AssertEquals(t, len(counts), numShards)
+	for shard, count := range counts {
+		test.Assert(t, count > numSerials/numShards/2, fmt.Sprintf("shard %d is underpopulated: %d", shard, count))
+		test.Assert(t, count < numSerials/numShards*2, fmt.Sprintf("shard %d is overpopulated: %d", shard, count))
+	}
+}
+
+func TestValidateRegistrationKey(t *testing.T) {
+	t.Parallel()
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating EC key")
+	smallRSAKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	test.AssertNotError(t, err, "generating RSA key")
+
+	err = validateRegistrationKey(jose.JSONWebKey{Key: ecKey.Public()}, goodkey.LetsEncryptCPS())
+	test.AssertNotError(t, err, "P-256 key should be allowed")
+
+	err = validateRegistrationKey(jose.JSONWebKey{Key: smallRSAKey.Public()}, goodkey.LetsEncryptCPS())
+	test.AssertErrorIs(t, err, berrors.BadPublicKey)
+	test.AssertContains(t, err.Error(), "RSA key size not supported: 1024")
+
+	err = validateRegistrationKey(jose.JSONWebKey{Key: ecKey.Public()}, goodkey.AllowedKeys{RSA2048: true})
+	test.AssertErrorIs(t, err, berrors.BadPublicKey)
+
+	err = validateRegistrationKey(jose.JSONWebKey{Key: []byte("symmetric")}, goodkey.LetsEncryptCPS())
+	test.AssertErrorIs(t, err, berrors.BadPublicKey)
+
+	// registrationPbToModel should refuse to produce a model for a bad key.
+	jwkJSON, err := jose.JSONWebKey{Key: smallRSAKey.Public()}.MarshalJSON()
+	test.AssertNotError(t, err, "marshaling JWK")
+	_, err = registrationPbToModel(&corepb.Registration{Key: jwkJSON}, goodkey.LetsEncryptCPS())
+	test.AssertErrorIs(t, err, berrors.BadPublicKey)
+
+	jwkJSON, err = jose.JSONWebKey{Key: ecKey.Public()}.MarshalJSON()
+	test.AssertNotError(t, err, "marshaling JWK")
+	_, err = registrationPbToModel(&corepb.Registration{Key: jwkJSON}, goodkey.LetsEncryptCPS())
+	test.AssertNotError(t, err, "registrationPbToModel with a P-256 key")
+}
+
+func TestDeactivateRegistrationIdempotent(t *testing.T) {
+	sa, _ := initSA(t)
+
+	reg := createWorkingRegistration(t, sa)
+
+	changed, err := DeactivateRegistration(ctx, sa.dbMap, reg.Id)
+	test.AssertNotError(t, err, "deactivating active account")
+	test.Assert(t, changed, "expected an active account to be deactivated")
+
+	got, err := sa.GetRegistration(ctx, &sapb.RegistrationID{Id: reg.Id})
+	test.AssertNotError(t, err, "fetching deactivated account")
+	test.AssertEquals(t, got.Status, string(core.StatusDeactivated))
+
+	changed, err = DeactivateRegistration(ctx, sa.dbMap, reg.Id)
+	test.AssertNotError(t, err, "deactivating already-deactivated account")
+	test.Assert(t, !changed, "expected no change for an already-deactivated account")
+}
+
+func TestDeleteExpiredOrderFQDNSets(t *testing.T) {
+	sa, fc := initSA(t)
+
+	reg := createWorkingRegistration(t, sa)
+	now := fc.Now()
+	for i := range 5 {
+		err := addOrderFQDNSet(ctx, sa.dbMap,
+			identifier.ACMEIdentifiers{identifier.NewDNS(fmt.Sprintf("expired%d.example.com", i))},
+			int64(i+1), reg.Id, now.Add(-time.Hour))
+		test.AssertNotError(t, err, "adding expired orderFQDNSet")
+	}
+	err := addOrderFQDNSet(ctx, sa.dbMap,
+		identifier.ACMEIdentifiers{identifier.NewDNS("current.example.com")},
+		100, reg.Id, now.Add(time.Hour))
+	test.AssertNotError(t, err, "adding unexpired orderFQDNSet")
+
+	_, err = DeleteExpiredOrderFQDNSets(ctx, sa.dbMap, now, 0)
+	test.AssertError(t, err, "expected error for zero limit")
+
+	// Deletion is bounded by limit, so loop until nothing is left to delete.
+	var total int64
+	for {
+		deleted, err := DeleteExpiredOrderFQDNSets(ctx, sa.dbMap, now, 2)
+		test.AssertNotError(t, err, "deleting expired orderFQDNSets")
+		test.Assert(t, deleted <= 2, "deleted more rows than limit")
+		if deleted == 0 {
+			break
+		}
+		total += deleted
+	}
+	test.AssertEquals(t, total, int64(5))
+
+	var remaining []orderFQDNSet
+	_, err = sa.dbMap.Select(ctx, &remaining, "SELECT id, setHash, orderID, registrationID, expires FROM orderFqdnSets")
+	test.AssertNotError(t, err, "selecting remaining orderFQDNSets")
+	test.AssertEquals(t, len(remaining), 1)
+	test.AssertEquals(t, remaining[0].OrderID, int64(100))
+}
+
+func TestBackfillFQDNSet(t *testing.T) {
+	sa, fc := initSA(t)
+
+	cert := &x509.Certificate{
+		SerialNumber: big.NewInt(1337),
+		DNSNames:     []string{"example.com", "www.example.com"},
+		NotBefore:    fc.Now(),
+		NotAfter:     fc.Now().Add(90 * 24 * time.Hour),
+	}
+	idents := identifier.FromCert(cert)
+
+	history, err := SelectFQDNSetHistory(ctx, sa.dbMap, idents, 10)
+	test.AssertNotError(t, err, "selecting fqdnSet history")
+	test.AssertEquals(t, len(history), 0)
+
+	err = BackfillFQDNSet(ctx, sa.dbMap, cert)
+	test.AssertNotError(t, err, "BackfillFQDNSet failed")
+	history, err = SelectFQDNSetHistory(ctx, sa.dbMap, idents, 10)
+	test.AssertNotError(t, err, "selecting fqdnSet history")
+	test.AssertEquals(t, len(history), 1)
+	test.AssertEquals(t, history[0].Serial, core.SerialToString(cert.SerialNumber))
+
+	// Backfilling again should not add another row.
+	err = BackfillFQDNSet(ctx, sa.dbMap, cert)
+	test.AssertNotError(t, err, "BackfillFQDNSet failed")
+	history, err = SelectFQDNSetHistory(ctx, sa.dbMap, idents, 10)
+	test.AssertNotError(t, err, "selecting fqdnSet history")
+	test.AssertEquals(t, len(history), 1)
+}
+
+func TestSelectFQDNSetHistory(t *testing.T) {
+	sa, fc := initSA(t)
+
+	idents := identifier.ACMEIdentifiers{
+		identifier.NewDNS("a.example.com"),
+		identifier.NewDNS("b.example.com"),
+	}
+	now := fc.Now()
+	for i, serial := range []string{"first", "second", "third"} {
+		issued := now.Add(time.Duration(i) * time.Hour)
+		err := addFQDNSet(ctx, sa.dbMap, idents, serial, issued, issued.Add(90*24*time.Hour))
+		test.AssertNotError(t, err, "adding fqdnSet")
+	}
+	// An issuance for a subset of the identifiers is a different set.
+	err := addFQDNSet(ctx, sa.dbMap, idents[:1], "other", now, now.Add(90*24*time.Hour))
+	test.AssertNotError(t, err, "adding fqdnSet")
+
+	_, err = SelectFQDNSetHistory(ctx, sa.dbMap, idents, 0)
+	test.AssertError(t, err, "expected error for zero limit")
+
+	history, err := SelectFQDNSetHistory(ctx, sa.dbMap, idents, 10)
+	test.AssertNotError(t, err, "selecting fqdnSet history")
+	test.AssertEquals(t, len(history), 3)
+	test.AssertEquals(t, history[0].Serial, "third")
+	test.AssertEquals(t, history[1].Serial, "second")
+	test.AssertEquals(t, history[2].Serial, "first")
+	test.Assert(t, history[0].Issued.After(history[1].Issued), "history should be ordered most recent first")
+	test.Assert(t, history[1].Issued.After(history[2].Issued), "history should be ordered most recent first")
+
+	history, err = SelectFQDNSetHistory(ctx, sa.dbMap, idents, 2)
+	test.AssertNotError(t, err, "selecting fqdnSet history")
+	test.AssertEquals(t, len(history), 2)
+	test.AssertEquals(t, history[0].Serial, "third")
+}
+
+func TestNewCertificateStatusForTest(t *testing.T) {
+	t.Parallel()
+
+	notAfter := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	revokedDate := time.Date(2029, 6, 1, 12, 0, 0, 0, time.UTC)
+	status := NewCertificateStatusForTest("00000000000000000000000000000000000a", core.OCSPStatusRevoked, notAfter, revokedDate, revocation.KeyCompromise, 1234)
+
+	test.AssertEquals(t, status.Serial, "00000000000000000000000000000000000a")
+	test.AssertEquals(t, status.Status, string(core.OCSPStatusRevoked))
+	test.AssertEquals(t, status.NotAfter.AsTime(), notAfter)
+	test.AssertEquals(t, status.RevokedDate.AsTime(), revokedDate)
+	test.AssertEquals(t, status.RevokedReason, int64(revocation.KeyCompromise))
+	test.AssertEquals(t, status.IssuerID, int64(1234))
+	test.AssertEquals(t, status.OcspLastUpdated.AsTime(), time.Time{}.UTC())
+	test.AssertEquals(t, status.LastExpirationNagSent.AsTime(), time.Time{}.UTC())
+	test.Assert(t, !status.IsExpired, "IsExpired should not be set")
+}
+
+func TestVerifyCertificateDigest(t *testing.T) {
+	t.Parallel()
+
+	der := []byte("not really a certificate, but it hashes all the same")
+	cert := &corepb.Certificate{
+		Serial: "0000000000000000000000000000000000ff",
+		Digest: core.Fingerprint256(der),
+		Der:    der,
+	}
+	test.AssertNotError(t, VerifyCertificateDigest(cert), "matching digest should verify")
+
+	tampered := proto.Clone(cert).(*corepb.Certificate)
+	tampered.Der = append([]byte{0x00}, tampered.Der...)
+	err := VerifyCertificateDigest(tampered)
+	test.AssertError(t, err, "tampered DER should not verify")
+	test.AssertContains(t, err.Error(), cert.Serial)
+}
+
+func TestSelectEarliestAuthzExpiryForOrder(t *testing.T) {
+	sa, fc := initSA(t)
+
+	reg := createWorkingRegistration(t, sa)
+	now := fc.Now()
+	earliest := now.Add(time.Hour).Truncate(time.Second)
+	authzA := createPendingAuthorization(t, sa, reg.Id, identifier.NewDNS("a.example.com"), now.Add(3*time.Hour))
+	authzB := createPendingAuthorization(t, sa, reg.Id, identifier.NewDNS("b.example.com"), earliest)
+	authzC := createPendingAuthorization(t, sa, reg.Id, identifier.NewDNS("c.example.com"), now.Add(2*time.Hour))
+
+	order, err := sa.NewOrderAndAuthzs(ctx, &sapb.NewOrderAndAuthzsRequest{
+		NewOrder: &sapb.NewOrderRequest{
+			RegistrationID:   reg.Id,
+			Expires:          timestamppb.New(now.Add(4 * time.Hour)),
+			V2Authorizations: []int64{authzA, authzB, authzC},
+			Identifiers: identifier.ACMEIdentifiers{
+				identifier.NewDNS("a.example.com"),
+				identifier.NewDNS("b.example.com"),
+				identifier.NewDNS("c.example.com"),
+			}.ToProtoSlice(),
+		},
+	})
+	test.AssertNotError(t, err, "NewOrderAndAuthzs failed")
+
+	expires, err := SelectEarliestAuthzExpiryForOrder(ctx, sa.dbMap, order.Id)
+	test.AssertNotError(t, err, "SelectEarliestAuthzExpiryForOrder failed")
+	test.AssertEquals(t, expires.UTC(), earliest.UTC())
+
+	_, err = SelectEarliestAuthzExpiryForOrder(ctx, sa.dbMap, order.Id+1000)
+	test.AssertErrorIs(t, err, berrors.NotFound)
+}
+
+func TestSelectAuthzsExpiringBetween(t *testing.T) {
+	sa, fc := initSA(t)
+
+	reg := createWorkingRegistration(t, sa)
+	other := createWorkingRegistration(t, sa)
+	start := fc.Now().Add(24 * time.Hour)
+	end := start.Add(24 * time.Hour)
+
+	// Expiring exactly at the start of the window is included; expiring exactly
+	// at the end is not.
+	atStart := createPendingAuthorization(t, sa, reg.Id, identifier.NewDNS("a.example.com"), start)
+	within := createPendingAuthorization(t, sa, reg.Id, identifier.NewDNS("b.example.com"), start.Add(time.Hour))
+	createPendingAuthorization(t, sa, reg.Id, identifier.NewDNS("c.example.com"), end)
+	createPendingAuthorization(t, sa, reg.Id, identifier.NewDNS("d.example.com"), start.Add(-time.Second))
+	// Non-pending authorizations, and those of other accounts, are excluded.
+	createFinalizedAuthorization(t, sa, reg.Id, identifier.NewDNS("e.example.com"), start.Add(time.Hour), "valid", fc.Now())
+	createFinalizedAuthorization(t, sa, reg.Id, identifier.NewDNS("f.example.com"), start.Add(time.Hour), "invalid", fc.Now())
+	createPendingAuthorization(t, sa, other.Id, identifier.NewDNS("g.example.com"), start.Add(time.Hour))
+
+	authzs, err := SelectAuthzsExpiringBetween(ctx, sa.dbMap, reg.Id, start, end, 10)
+	test.AssertNotError(t, err, "SelectAuthzsExpiringBetween failed")
+	test.AssertEquals(t, len(authzs), 2)
+	test.AssertEquals(t, authzs[0].Id, atStart)
+	test.AssertEquals(t, authzs[1].Id, within)
+
+	authzs, err = SelectAuthzsExpiringBetween(ctx, sa.dbMap, reg.Id, start, end, 1)
+	test.AssertNotError(t, err, "SelectAuthzsExpiringBetween failed")
+	test.AssertEquals(t, len(authzs), 1)
+	test.AssertEquals(t, authzs[0].Id, atStart)
+
+	_, err = SelectAuthzsExpiringBetween(ctx, sa.dbMap, reg.Id, start, end, 0)
+	test.AssertError(t, err, "a zero limit should be rejected")
+}
+
+func TestAuthorizationBelongsTo(t *testing.T) {
+	sa, fc := initSA(t)
+
+	reg := createWorkingRegistration(t, sa)
+	other := createWorkingRegistration(t, sa)
+	authzID := createPendingAuthorization(t, sa, reg.Id, identifier.NewDNS("example.com"), fc.Now().Add(time.Hour))
+
+	belongs, err := AuthorizationBelongsTo(ctx, sa.dbMap, authzID, reg.Id)
+	test.AssertNotError(t, err, "AuthorizationBelongsTo failed")
+	test.Assert(t, belongs, "authz should belong to its owner")
+
+	belongs, err = AuthorizationBelongsTo(ctx, sa.dbMap, authzID, other.Id)
+	test.AssertNotError(t, err, "AuthorizationBelongsTo failed")
+	test.Assert(t, !belongs, "authz should not belong to another account")
+
+	_, err = AuthorizationBelongsTo(ctx, sa.dbMap, authzID+100, reg.Id)
+	test.AssertErrorIs(t, err, berrors.NotFound)
+}
+
+func TestCountFailedAuthorizations(t *testing.T) {
+	sa, fc := initSA(t)
+
+	reg := createWorkingRegistration(t, sa)
+	other := createWorkingRegistration(t, sa)
+	ident := identifier.NewDNS("example.com")
+	expires := fc.Now().Add(time.Hour)
+	since := fc.Now().Add(-time.Hour)
+
+	// Two invalid authorizations within the window are counted.
+	createFinalizedAuthorization(t, sa, reg.Id, ident, expires, "invalid", fc.Now())
+	createFinalizedAuthorization(t, sa, reg.Id, ident, expires, "invalid", since)
+	// Invalid authorizations attempted before the window, valid or pending
+	// authorizations, and those for other identifiers or accounts are not.
+	createFinalizedAuthorization(t, sa, reg.Id, ident, expires, "invalid", since.Add(-time.Second))
+	createFinalizedAuthorization(t, sa, reg.Id, ident, expires, "valid", fc.Now())
+	createPendingAuthorization(t, sa, reg.Id, ident, expires)
+	createFinalizedAuthorization(t, sa, reg.Id, identifier.NewDNS("example.net"), expires, "invalid", fc.Now())
+	createFinalizedAuthorization(t, sa, other.Id, ident, expires, "invalid", fc.Now())
+
+	count, err := CountFailedAuthorizations(ctx, sa.dbMap, reg.Id, ident, since)
+	test.AssertNotError(t, err, "CountFailedAuthorizations failed")
+	test.AssertEquals(t, count, int64(2))
+
+	count, err = CountFailedAuthorizations(ctx, sa.dbMap, reg.Id, identifier.NewDNS("example.org"), since)
+	test.AssertNotError(t, err, "CountFailedAuthorizations failed")
+	test.AssertEquals(t, count, int64(0))
+}
+
+func TestSelectAttemptedChallengeTypes(t *testing.T) {
+	sa, fc := initSA(t)
+
+	reg := createWorkingRegistration(t, sa)
+	other := createWorkingRegistration(t, sa)
+	ident := identifier.NewDNS("example.com")
+
+	insertAuthz := func(regID int64, ident identifier.ACMEIdentifier, attempted *core.AcmeChallenge) {
+		t.Helper()
+		am := authzModel{
+			IdentifierType:  identifierTypeToUint[string(ident.Type)],
+			IdentifierValue: ident.Value,
+			RegistrationID:  regID,
+			Status:          statusToUint[core.StatusPending],
+			Expires:         fc.Now().Add(time.Hour),
+			Token:           []byte("token"),
+		}
+		for _, chall := range []core.AcmeChallenge{core.ChallengeTypeHTTP01, core.ChallengeTypeDNS01, core.ChallengeTypeTLSALPN01} {
+			am.Challenges |= 1 << challTypeToUint[string(chall)]
+		}
+		if attempted != nil {
+			a := challTypeToUint[string(*attempted)]
+			am.Attempted = &a
+			am.Status = statusToUint[core.StatusInvalid]
+		}
+		err := sa.dbMap.Insert(ctx, &am)
+		test.AssertNotError(t, err, "inserting authorization")
+	}
+
+	http01, dns01, tlsalpn01 := core.ChallengeTypeHTTP01, core.ChallengeTypeDNS01, core.ChallengeTypeTLSALPN01
+	insertAuthz(reg.Id, ident, &http01)
+	insertAuthz(reg.Id, ident, &dns01)
+	insertAuthz(reg.Id, ident, &http01)
+	insertAuthz(reg.Id, ident, nil)
+	// Attempts by other accounts, or for other identifiers, are excluded.
+	insertAuthz(other.Id, ident, &tlsalpn01)
+	insertAuthz(reg.Id, identifier.NewDNS("example.net"), &tlsalpn01)
+
+	challTypes, err := SelectAttemptedChallengeTypes(ctx, sa.dbMap, reg.Id, ident)
+	test.AssertNotError(t, err, "SelectAttemptedChallengeTypes failed")
+	test.AssertDeepEquals(t, challTypes, []string{"dns-01", "http-01"})
+
+	challTypes, err = SelectAttemptedChallengeTypes(ctx, sa.dbMap, reg.Id, identifier.NewDNS("example.org"))
+	test.AssertNotError(t, err, "SelectAttemptedChallengeTypes failed")
+	test.AssertEquals(t, len(challTypes), 0)
+}
+
+func TestSelectDistinctCertificateProfiles(t *testing.T) {
+	sa, fc := initSA(t)
+
+	reg := createWorkingRegistration(t, sa)
+	now := fc.Now()
+	modern, legacy := "modern", "legacy"
+	for _, profile := range []*string{&modern, &legacy, &modern, nil} {
+		err := sa.dbMap.Insert(ctx, &orderModel{
+			RegistrationID:         reg.Id,
+			Expires:                now.Add(time.Hour),
+			Created:                now,
+			CertificateProfileName: profile,
+		})
+		test.AssertNotError(t, err, "inserting order")
+	}
+
+	profiles, err := SelectDistinctCertificateProfiles(ctx, sa.dbMap)
+	test.AssertNotError(t, err, "SelectDistinctCertificateProfiles failed")
+	test.AssertDeepEquals(t, profiles, []string{"legacy", "modern"})
+}
+
+func TestSelectOrdersByRegistrationID(t *testing.T) {
+	sa, fc := initSA(t)
+
+	reg := createWorkingRegistration(t, sa)
+	other := createWorkingRegistration(t, sa)
+	now := fc.Now()
+	profile, replaces := "modern", "0000000000000000000000000000000000aa"
+
+	insert := func(regID int64, profile, replaces *string) int64 {
+		t.Helper()
+		om := orderModel{
+			RegistrationID:         regID,
+			Expires:                now.Add(time.Hour),
+			Created:                now,
+			CertificateProfileName: profile,
+			Replaces:               replaces,
+		}
+		err := sa.dbMap.Insert(ctx, &om)
+		test.AssertNotError(t, err, "inserting order")
+		return om.ID
+	}
+	withProfile := insert(reg.Id, &profile, nil)
+	insert(other.Id, &profile, nil)
+	withoutProfile := insert(reg.Id, nil, nil)
+	withReplaces := insert(reg.Id, &profile, &replaces)
+
+	orders, cursor, err := SelectOrdersByRegistrationID(ctx, sa.dbMap, reg.Id, 0, 10)
+	test.AssertNotError(t, err, "SelectOrdersByRegistrationID failed")
+	test.AssertEquals(t, cursor, int64(0))
+	test.AssertEquals(t, len(orders), 3)
+	test.AssertEquals(t, orders[0].Id, withProfile)
+	test.AssertEquals(t, orders[0].CertificateProfileName, "modern")
+	test.AssertEquals(t, orders[0].Replaces, "")
+	test.AssertEquals(t, orders[1].Id, withoutProfile)
+	test.AssertEquals(t, orders[1].CertificateProfileName, "")
+	test.AssertEquals(t, orders[1].Replaces, "")
+	test.AssertEquals(t, orders[2].Id, withReplaces)
+	test.AssertEquals(t, orders[2].CertificateProfileName, "modern")
+	test.AssertEquals(t, orders[2].Replaces, replaces)
+	for _, order := range orders {
+		test.AssertEquals(t, order.RegistrationID, reg.Id)
+	}
+
+	// Page through the same orders two at a time.
+	orders, cursor, err = SelectOrdersByRegistrationID(ctx, sa.dbMap, reg.Id, 0, 2)
+	test.AssertNotError(t, err, "SelectOrdersByRegistrationID failed")
+	test.AssertEquals(t, len(orders), 2)
+	test.AssertEquals(t, cursor, withoutProfile)
+	orders, cursor, err = SelectOrdersByRegistrationID(ctx, sa.dbMap, reg.Id, cursor, 2)
+	test.AssertNotError(t, err, "SelectOrdersByRegistrationID failed")
+	test.AssertEquals(t, len(orders), 1)
+	test.AssertEquals(t, orders[0].Id, withReplaces)
+	test.AssertEquals(t, cursor, int64(0))
+
+	_, _, err = SelectOrdersByRegistrationID(ctx, sa.dbMap, reg.Id, 0, 0)
+	test.AssertError(t, err, "a zero limit should be rejected")
+}
+
+func TestCheckSerialUniqueness(t *testing.T) {
+	sa, fc := initSA(t)
+
+	insert := func(serial string, precert bool) {
+		t.Helper()
+		var err error
+		if precert {
+			err = sa.dbMap.Insert(ctx, &lintingCertModel{
+				RegistrationID: 1,
+				Serial:         serial,
+				DER:            []byte{1},
+				Issued:         fc.Now(),
+				Expires:        fc.Now().Add(time.Hour),
+			})
+		} else {
+			err = sa.dbMap.Insert(ctx, &core.Certificate{
+				RegistrationID: 1,
+				Serial:         serial,
+				Digest:         "digest",
+				DER:            []byte{1},
+				Issued:         fc.Now(),
+				Expires:        fc.Now().Add(time.Hour),
+			})
+		}
+		test.AssertNotError(t, err, "inserting certificate")
+	}
+
+	const normal = "00000000000000000000000000000000000a"
+	insert(normal, true)
+	insert(normal, false)
+	certCount, precertCount, err := CheckSerialUniqueness(ctx, sa.dbMap, normal)
+	test.AssertNotError(t, err, "CheckSerialUniqueness failed")
+	test.AssertEquals(t, certCount, 1)
+	test.AssertEquals(t, precertCount, 1)
+
+	const duplicated = "00000000000000000000000000000000000b"
+	insert(duplicated, true)
+	insert(duplicated, false)
+	insert(duplicated, false)
+	certCount, precertCount, err = CheckSerialUniqueness(ctx, sa.dbMap, duplicated)
+	test.AssertNotError(t, err, "CheckSerialUniqueness failed")
+	test.AssertEquals(t, certCount, 2)
+	test.AssertEquals(t, precertCount, 1)
+
+	certCount, precertCount, err = CheckSerialUniqueness(ctx, sa.dbMap, "00000000000000000000000000000000000c")
+	test.AssertNotError(t, err, "CheckSerialUniqueness failed")
+	test.AssertEquals(t, certCount, 0)
+	test.AssertEquals(t, precertCount, 0)
+}
+
+func TestSelectStaleCRLShards(t *testing.T) {
+	sa, clk := initSA(t)
+
+	// Shard 0 is fresh, shard 1 is stale, and shard 2 is stale but currently
+	// leased. A stale shard for another issuer must not be returned either.
+	_, err := sa.dbMap.ExecContext(ctx,
+		`INSERT INTO crlShards (issuerID, idx, thisUpdate, nextUpdate, leasedUntil) VALUES
+		(1, 0, ?, ?, ?),
+		(1, 1, ?, ?, ?),
+		(1, 2, ?, ?, ?),
+		(2, 0, ?, ?, ?);`,
+		clk.Now().Add(-time.Hour), clk.Now().Add(5*24*time.Hour), clk.Now().Add(-time.Hour),
+		clk.Now().Add(-7*24*time.Hour), clk.Now().Add(time.Hour), clk.Now().Add(-time.Hour),
+		clk.Now().Add(-7*24*time.Hour), clk.Now().Add(time.Hour), clk.Now().Add(time.Hour),
+		clk.Now().Add(-7*24*time.Hour), clk.Now().Add(time.Hour), clk.Now().Add(-time.Hour),
+	)
+	test.AssertNotError(t, err, "setting up test shards")
+
+	shards, err := SelectStaleCRLShards(ctx, sa.dbMap, 1, clk.Now().Add(24*time.Hour), clk.Now())
+	test.AssertNotError(t, err, "SelectStaleCRLShards failed")
+	test.AssertEquals(t, len(shards), 1)
+	test.AssertEquals(t, shards[0].IssuerID, int64(1))
+	test.AssertEquals(t, shards[0].Idx, 1)
+}
+
+func TestSetCRLShardUpdated(t *testing.T) {
+	sa, clk := initSA(t)
+
+	_, err := sa.dbMap.ExecContext(ctx,
+		`INSERT INTO crlShards (issuerID, idx, thisUpdate, nextUpdate, leasedUntil) VALUES
+		(1, 0, NULL, NULL, ?);`,
+		clk.Now().Add(time.Hour),
+	)
+	test.AssertNotError(t, err, "setting up test shard")
+
+	thisUpdate := clk.Now().Truncate(time.Second).UTC()
+	nextUpdate := thisUpdate.Add(24 * time.Hour)
+	err = SetCRLShardUpdated(ctx, sa.dbMap, 1, 0, thisUpdate, nextUpdate)
+	test.AssertNotError(t, err, "SetCRLShardUpdated failed")
+
+	var shard crlShardModel
+	err = sa.dbMap.SelectOne(ctx, &shard,
+		`SELECT id, issuerID, idx, thisUpdate, nextUpdate, leasedUntil FROM crlShards WHERE issuerID = ? AND idx = ?`,
+		1, 0,
+	)
+	test.AssertNotError(t, err, "selecting updated shard")
+	test.AssertNotNil(t, shard.ThisUpdate, "thisUpdate should be set")
+	test.AssertNotNil(t, shard.NextUpdate, "nextUpdate should be set")
+	test.Assert(t, shard.ThisUpdate.Equal(thisUpdate), "checking updated thisUpdate timestamp")
+	test.Assert(t, shard.NextUpdate.Equal(nextUpdate), "checking updated nextUpdate timestamp")
+	test.Assert(t, !shard.LeasedUntil.After(clk.Now()), "shard should no longer be leased")
+
+	err = SetCRLShardUpdated(ctx, sa.dbMap, 1, 1, thisUpdate, nextUpdate)
+	test.AssertErrorIs(t, err, berrors.NotFound)
+}
+
+func TestSelectRegistrationIDForSerial(t *testing.T) {
+	sa, fc := initSA(t)
+
+	const (
+		finalSerial   = "00000000000000000000000000000000000a"
+		precertSerial = "00000000000000000000000000000000000b"
+	)
+	err := sa.dbMap.Insert(ctx, &lintingCertModel{
+		RegistrationID: 1,
+		Serial:         finalSerial,
+		DER:            []byte{1},
+		Issued:         fc.Now(),
+		Expires:        fc.Now().Add(time.Hour),
+	})
+	test.AssertNotError(t, err, "inserting precertificate")
+	err = sa.dbMap.Insert(ctx, &core.Certificate{
+		RegistrationID: 1,
+		Serial:         finalSerial,
+		Digest:         "digest",
+		DER:            []byte{1},
+		Issued:         fc.Now(),
+		Expires:        fc.Now().Add(time.Hour),
+	})
+	test.AssertNotError(t, err, "inserting certificate")
+	err = sa.dbMap.Insert(ctx, &lintingCertModel{
+		RegistrationID: 2,
+		Serial:         precertSerial,
+		DER:            []byte{2},
+		Issued:         fc.Now(),
+		Expires:        fc.Now().Add(time.Hour),
+	})
+	test.AssertNotError(t, err, "inserting precertificate")
+
+	regID, err := SelectRegistrationIDForSerial(ctx, sa.dbMap, finalSerial)
+	test.AssertNotError(t, err, "SelectRegistrationIDForSerial failed")
+	test.AssertEquals(t, regID, int64(1))
+
+	regID, err = SelectRegistrationIDForSerial(ctx, sa.dbMap, precertSerial)
+	test.AssertNotError(t, err, "SelectRegistrationIDForSerial failed")
+	test.AssertEquals(t, regID, int64(2))
+
+	_, err = SelectRegistrationIDForSerial(ctx, sa.dbMap, "00000000000000000000000000000000000c")
+	test.AssertErrorIs(t, err, berrors.UnknownSerial)
+}
+
+func TestSelectPrecertificateDER(t *testing.T) {
+	sa, fc := initSA(t)
+
+	const serial = "00000000000000000000000000000000000a"
+	err := sa.dbMap.Insert(ctx, &lintingCertModel{
+		RegistrationID: 1,
+		Serial:         serial,
+		DER:            []byte{1, 2, 3},
+		Issued:         fc.Now(),
+		Expires:        fc.Now().Add(time.Hour),
+	})
+	test.AssertNotError(t, err, "inserting precertificate")
+
+	der, err := SelectPrecertificateDER(ctx, sa.dbMap, serial)
+	test.AssertNotError(t, err, "SelectPrecertificateDER failed")
+	test.AssertDeepEquals(t, der, []byte{1, 2, 3})
+
+	_, err = SelectPrecertificateDER(ctx, sa.dbMap, "00000000000000000000000000000000000b")
+	test.AssertErrorIs(t, err, berrors.NotFound)
+}
+
+func TestSummarizeAuthorizations(t *testing.T) {
+	sa, fc := initSA(t)
+
+	reg := createWorkingRegistration(t, sa)
+	other := createWorkingRegistration(t, sa)
+	now := fc.Now()
+	exp := now.Add(time.Hour)
+
+	createPendingAuthorization(t, sa, reg.Id, identifier.NewDNS("a.example.com"), exp)
+	createPendingAuthorization(t, sa, reg.Id, identifier.NewDNS("b.example.com"), exp)
+	createFinalizedAuthorization(t, sa, reg.Id, identifier.NewDNS("c.example.com"), exp, "valid", now)
+	createFinalizedAuthorization(t, sa, reg.Id, identifier.NewIP(netip.MustParseAddr("64.112.117.1")), exp, "valid", now)
+	createFinalizedAuthorization(t, sa, reg.Id, identifier.NewIP(netip.MustParseAddr("64.112.117.2")), exp, "invalid", now)
+
+	// Neither expired authorizations nor those belonging to other accounts
+	// should be counted.
+	createPendingAuthorization(t, sa, reg.Id, identifier.NewDNS("expired.example.com"), now.Add(-time.Hour))
+	createPendingAuthorization(t, sa, other.Id, identifier.NewDNS("other.example.com"), exp)
+
+	summary, err := SummarizeAuthorizations(ctx, sa.dbMap, reg.Id, now)
+	test.AssertNotError(t, err, "SummarizeAuthorizations failed")
+	test.AssertDeepEquals(t, summary, map[identifier.IdentifierType]map[core.AcmeStatus]int64{
+		identifier.TypeDNS: {
+			core.StatusPending: 2,
+			core.StatusValid:   1,
+		},
+		identifier.TypeIP: {
+			core.StatusValid:   1,
+			core.StatusInvalid: 1,
+		},
+	})
+}
+
+func TestSelectRegistrationByID(t *testing.T) {
+	sa, _ := initSA(t)
+
+	reg := createWorkingRegistration(t, sa)
+	got, err := SelectRegistrationByID(ctx, sa.dbMap, reg.Id)
+	test.AssertNotError(t, err, "SelectRegistrationByID failed")
+	test.AssertEquals(t, got.Id, reg.Id)
+	test.AssertByteEquals(t, got.Key, reg.Key)
+
+	_, err = SelectRegistrationByID(ctx, sa.dbMap, reg.Id+100)
+	test.AssertError(t, err, "selecting a nonexistent registration should fail")
+	test.AssertErrorIs(t, err, berrors.NotFound)
+	test.AssertContains(t, err.Error(), fmt.Sprintf("registration with ID %d not found", reg.Id+100))
+}
+
+func TestRegistrationModelToPbIncomplete(t *testing.T) {
+	_, err := registrationModelToPb(&regModel{Key: []byte("{}")})
+	test.AssertError(t, err, "registration without an ID should be rejected")
+	test.AssertContains(t, err.Error(), "incomplete Registration")
+
+	_, err = registrationModelToPb(&regModel{ID: 1})
+	test.AssertError(t, err, "registration without a key should be rejected")
+	test.AssertContains(t, err.Error(), "incomplete Registration")
+}
@@ -23,6 +23,7 @@ import (
 	corepb "github.com/letsencrypt/boulder/core/proto"
 	"github.com/letsencrypt/boulder/db"
 	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/goodkey"
 	bgrpc "github.com/letsencrypt/boulder/grpc"
 	"github.com/letsencrypt/boulder/identifier"
 	blog "github.com/letsencrypt/boulder/log"
@@ -47,12 +48,20 @@ type SQLStorageAuthority struct {
 
 	dbMap *db.WrappedMap
 
+	// allowedRegistrationKeys are the account key types and sizes which may
+	// be stored by NewRegistration.
+	allowedRegistrationKeys goodkey.AllowedKeys
+
 	// rateLimitWriteErrors is a Counter for the number of times
 	// a ratelimit update transaction failed during AddCertificate request
 	// processing. We do not fail the overall AddCertificate call when ratelimit
 	// transactions fail and so use this stat to maintain visibility into the rate
 	// this occurs.
 	rateLimitWriteErrors prometheus.Counter
+
+	// newAuthzChallengeTypes counts the challenge types offered by newly
+	// created authorizations, labeled by challenge type.
+	newAuthzChallengeTypes *prometheus.CounterVec
 }
 
 var _ sapb.StorageAuthorityServer = (*SQLStorageAuthority)(nil)
@@ -60,10 +69,12 @@ var _ sapb.StorageAuthorityServer = (*SQLStorageAuthority)(nil)
 // NewSQLStorageAuthorityWrapping provides persistence using a SQL backend for
 // Boulder. It takes a read-only storage authority to wrap, which is useful if
 // you are constructing both types of implementations and want to share
-// read-only database connections between them.
+// read-only database connections between them. If allowedKeys is nil, the
+// account keys allowed by the Let's Encrypt CPS are accepted.
 func NewSQLStorageAuthorityWrapping(
 	ssaro *SQLStorageAuthorityRO,
 	dbMap *db.WrappedMap,
+	allowedKeys *goodkey.AllowedKeys,
 	stats prometheus.Registerer,
 ) (*SQLStorageAuthority, error) {
 	rateLimitWriteErrors := promauto.With(stats).NewCounter(prometheus.CounterOpts{
@@ -71,10 +82,20 @@ func NewSQLStorageAuthorityWrapping(
 		Help: "number of failed ratelimit update transactions during AddCertificate",
 	})
 
+	newAuthzChallengeTypes := promauto.With(stats).NewCounterVec(prometheus.CounterOpts{
+		Name: "new_authz_challenge_types",
+		Help: "number of newly created authorizations offering each challenge type",
+	}, []string{"type"})
+
 	ssa := &SQLStorageAuthority{
-		SQLStorageAuthorityRO: ssaro,
-		dbMap:                 dbMap,
-		rateLimitWriteErrors:  rateLimitWriteErrors,
+		SQLStorageAuthorityRO:   ssaro,
+		dbMap:                   dbMap,
+		allowedRegistrationKeys: goodkey.LetsEncryptCPS(),
+		rateLimitWriteErrors:    rateLimitWriteErrors,
+		newAuthzChallengeTypes:  newAuthzChallengeTypes,
+	}
+	if allowedKeys != nil {
+		ssa.allowedRegistrationKeys = *allowedKeys
 	}
 
 	return ssa, nil
@@ -92,12 +113,12 @@ func NewSQLStorageAuthority(
 	stats prometheus.Registerer,
 ) (*SQLStorageAuthority, error) {
 	ssaro, err := NewSQLStorageAuthorityRO(
-		dbReadOnlyMap, dbIncidentsMap, stats, lagFactor, clk, logger)
+		dbReadOnlyMap, dbIncidentsMap, stats, lagFactor, 0, clk, logger)
 	if err != nil {
 		return nil, err
 	}
 
-	return NewSQLStorageAuthorityWrapping(ssaro, dbMap, stats)
+	return NewSQLStorageAuthorityWrapping(ssaro, dbMap, nil, stats)
 }
 
 // NewRegistration stores a new Registration
@@ -106,7 +127,7 @@ func (ssa *SQLStorageAuthority) NewRegistration(ctx context.Context, req *corepb
 		return nil, errIncompleteRequest
 	}
 
-	reg, err := registrationPbToModel(req)
+	reg, err := registrationPbToModel(req, ssa.allowedRegistrationKeys)
 	if err != nil {
 		return nil, err
 	}
@@ -485,7 +506,7 @@ func (ssa *SQLStorageAuthority) NewOrderAndAuthzs(ctx context.Context, req *sapb
 		// First, insert all of the new authorizations and record their IDs.
 		newAuthzIDs := make([]int64, 0, len(req.NewAuthzs))
 		for _, authz := range req.NewAuthzs {
-			am, err := newAuthzReqToModel(authz, req.NewOrder.CertificateProfileName)
+			am, err := newAuthzReqToModel(authz, req.NewOrder.CertificateProfileName, ssa.newAuthzChallengeTypes)
 			if err != nil {
 				return nil, err
 			}
@@ -533,9 +554,14 @@ func (ssa *SQLStorageAuthority) NewOrderAndAuthzs(ctx context.Context, req *sapb
 		}
 
 		if req.NewOrder.ReplacesSerial != "" {
+			err := ValidateReplacesSerial(ctx, tx, req.NewOrder.ReplacesSerial)
+			if err != nil {
+				return nil, err
+			}
+
 			// Update the replacementOrders table to indicate that this order
 			// replaces the provided certificate serial.
-			err := addReplacementOrder(ctx, tx, req.NewOrder.ReplacesSerial, orderID, req.NewOrder.Expires.AsTime())
+			err = addReplacementOrder(ctx, tx, req.NewOrder.ReplacesSerial, orderID, req.NewOrder.Expires.AsTime())
 			if err != nil {
 				return nil, err
 			}
@@ -730,7 +756,7 @@ func (ssa *SQLStorageAuthority) FinalizeOrder(ctx context.Context, req *sapb.Fin
 			return nil, err
 		}
 
-		err = setReplacementOrderFinalized(ctx, tx, req.Id)
+		err = setReplacementOrderFinalized(ctx, tx, req.Id, ssa.clk.Now())
 		if err != nil {
 			return nil, err
 		}
@@ -866,6 +892,44 @@ func addRevokedCertificate(ctx context.Context, tx db.Executor, req *sapb.Revoke
 	return nil
 }
 
+// markCertificateStatusRevoked updates the certificateStatus row for the given
+// serial to revoked, with the given reason and date. It returns a
+// BadRevocationReasonError if the reason is not permitted by the Baseline
+// Requirements, and an AlreadyRevokedError if there is no such row which is not
+// already revoked.
+func markCertificateStatusRevoked(ctx context.Context, e db.Execer, serial string, reason revocation.Reason, revokedDate time.Time) error {
+	err := revocation.ValidateRevocationReason(reason)
+	if err != nil {
+		return err
+	}
+
+	res, err := e.ExecContext(ctx,
+		`UPDATE certificateStatus SET
+			status = ?,
+			revokedReason = ?,
+			revokedDate = ?,
+			ocspLastUpdated = ?
+		WHERE serial = ? AND status != ?`,
+		string(core.OCSPStatusRevoked),
+		reason,
+		revokedDate,
+		revokedDate,
+		serial,
+		string(core.OCSPStatusRevoked),
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return berrors.AlreadyRevokedError("no certificate with serial %s and status other than %s", serial, string(core.OCSPStatusRevoked))
+	}
+	return nil
+}
+
 // RevokeCertificate stores revocation information about a certificate. It will only store this
 // information if the certificate is not already marked as revoked.
 //
@@ -879,30 +943,10 @@ func (ssa *SQLStorageAuthority) RevokeCertificate(ctx context.Context, req *sapb
 	_, overallError := db.WithTransaction(ctx, ssa.dbMap, func(tx db.Executor) (any, error) {
 		revokedDate := req.Date.AsTime()
 
-		res, err := tx.ExecContext(ctx,
-			`UPDATE certificateStatus SET
-				status = ?,
-				revokedReason = ?,
-				revokedDate = ?,
-				ocspLastUpdated = ?
-			WHERE serial = ? AND status != ?`,
-			string(core.OCSPStatusRevoked),
-			revocation.Reason(req.Reason),
-			revokedDate,
-			revokedDate,
-			req.Serial,
-			string(core.OCSPStatusRevoked),
-		)
+		err := markCertificateStatusRevoked(ctx, tx, req.Serial, revocation.Reason(req.Reason), revokedDate)
 		if err != nil {
 			return nil, err
 		}
-		rows, err := res.RowsAffected()
-		if err != nil {
-			return nil, err
-		}
-		if rows == 0 {
-			return nil, berrors.AlreadyRevokedError("no certificate with serial %s and status other than %s", req.Serial, string(core.OCSPStatusRevoked))
-		}
 
 		err = addRevokedCertificate(ctx, tx, req, revokedDate)
 		if err != nil {
@@ -106,12 +106,12 @@ func initSA(t testing.TB) (*SQLStorageAuthority, clock.FakeClock) {
 	fc := clock.NewFake()
 	fc.Set(mustTime("2015-03-04 05:00"))
 
-	saro, err := NewSQLStorageAuthorityRO(dbMap, dbIncidentsMap, metrics.NoopRegisterer, 0, fc, log)
+	saro, err := NewSQLStorageAuthorityRO(dbMap, dbIncidentsMap, metrics.NoopRegisterer, 0, 0, fc, log)
 	if err != nil {
 		t.Fatalf("Failed to create SA: %s", err)
 	}
 
-	sa, err := NewSQLStorageAuthorityWrapping(saro, dbMap, metrics.NoopRegisterer)
+	sa, err := NewSQLStorageAuthorityWrapping(saro, dbMap, nil, metrics.NoopRegisterer)
 	if err != nil {
 		t.Fatalf("Failed to create SA: %s", err)
 	}
@@ -944,6 +944,45 @@ func TestEncodeIssuedName(t *testing.T) {
 	}
 }
 
+func TestDecodeIssuedName(t *testing.T) {
+	testCases := []struct {
+		stored  string
+		ident   identifier.ACMEIdentifier
+		display string
+	}{
+		{"com.example", identifier.NewDNS("example.com"), "example.com"},
+		{"com.example.www", identifier.NewDNS("www.example.com"), "www.example.com"},
+		{"64.112.117.1", identifier.NewIP(netip.MustParseAddr("64.112.117.1")), "64.112.117.1"},
+		{"2602:ff3a:1:abad:c0f:fee:abad:cafe", identifier.NewIP(netip.MustParseAddr("2602:ff3a:1:abad:c0f:fee:abad:cafe")), "2602:ff3a:1:abad:c0f:fee:abad:cafe"},
+		// FQDNs that look like IPv6 addresses are still FQDNs.
+		{"cafe.abad.fee.c0f.abad.1.ff3a.2602", identifier.NewDNS("2602.ff3a.1.abad.c0f.fee.abad.cafe"), "2602.ff3a.1.abad.c0f.fee.abad.cafe"},
+	}
+
+	for _, tc := range testCases {
+		test.AssertEquals(t, DecodeIssuedName(tc.stored), tc.ident)
+		test.AssertEquals(t, FormatIssuedNameForDisplay(tc.stored), tc.display)
+		test.AssertEquals(t, EncodeIssuedName(DecodeIssuedName(tc.stored).Value), tc.stored)
+	}
+}
+
+func TestIssuedNameRoundTrip(t *testing.T) {
+	for _, name := range []string{
+		"example.com",
+		"www.example.com",
+		"a.b.c.d.example.co.uk",
+		// Single-label names are their own reversal.
+		"localhost",
+		"com",
+		"64.112.117.1",
+		"2602:ff3a:1:abad:c0f:fee:abad:cafe",
+		"::1",
+	} {
+		encoded := EncodeIssuedName(name)
+		test.AssertEquals(t, DecodeIssuedName(encoded).Value, name)
+		test.AssertEquals(t, FormatIssuedNameForDisplay(encoded), name)
+	}
+}
+
 func TestNewOrderAndAuthzs(t *testing.T) {
 	sa, _ := initSA(t)
 
@@ -1693,6 +1732,46 @@ func TestGetOrderForNames(t *testing.T) {
 	test.Assert(t, result == nil, "sa.GetOrderForNames returned non-nil result for finalized order case")
 }
 
+func TestGetOrderForNamesProfile(t *testing.T) {
+	sa, fc := initSA(t)
+
+	reg := createWorkingRegistration(t, sa)
+	expires := fc.Now().Add(time.Hour)
+	ident := identifier.NewDNS("example.com")
+	authzID := createPendingAuthorization(t, sa, reg.Id, ident, expires)
+
+	order, err := sa.NewOrderAndAuthzs(ctx, &sapb.NewOrderAndAuthzsRequest{
+		NewOrder: &sapb.NewOrderRequest{
+			RegistrationID:         reg.Id,
+			Expires:                timestamppb.New(expires),
+			V2Authorizations:       []int64{authzID},
+			Identifiers:            []*corepb.Identifier{ident.ToProto()},
+			CertificateProfileName: "modern",
+		},
+	})
+	test.AssertNotError(t, err, "sa.NewOrderAndAuthzs failed")
+
+	// A request for the same profile reuses the order.
+	result, err := sa.GetOrderForNames(ctx, &sapb.GetOrderForNamesRequest{
+		AcctID:                 reg.Id,
+		Identifiers:            []*corepb.Identifier{ident.ToProto()},
+		CertificateProfileName: "modern",
+	})
+	test.AssertNotError(t, err, "sa.GetOrderForNames failed")
+	test.AssertEquals(t, result.Id, order.Id)
+
+	// A request for a different profile, or for no profile, does not.
+	for _, profile := range []string{"legacy", ""} {
+		result, err = sa.GetOrderForNames(ctx, &sapb.GetOrderForNamesRequest{
+			AcctID:                 reg.Id,
+			Identifiers:            []*corepb.Identifier{ident.ToProto()},
+			CertificateProfileName: profile,
+		})
+		test.AssertErrorIs(t, err, berrors.NotFound)
+		test.Assert(t, result == nil, "sa.GetOrderForNames returned an order with a different profile")
+	}
+}
+
 func TestStatusForOrder(t *testing.T) {
 	sa, fc := initSA(t)
 
@@ -1972,6 +2051,16 @@ func TestRevokeCertificateWithShard(t *testing.T) {
 	test.AssertNotError(t, err, "should be exactly one row in revokedCertificates")
 	test.AssertEquals(t, result.ShardIdx, int64(9))
 	test.AssertEquals(t, result.RevokedReason, revocation.KeyCompromise)
+
+	// Revoking an already-revoked certificate should fail.
+	_, err = sa.RevokeCertificate(context.Background(), &sapb.RevokeCertificateRequest{
+		IssuerID: 1,
+		ShardIdx: 9,
+		Serial:   serial,
+		Date:     timestamppb.New(now),
+		Reason:   reason,
+	})
+	test.AssertErrorIs(t, err, berrors.AlreadyRevoked)
 }
 
 func TestUpdateRevokedCertificate(t *testing.T) {
@@ -3553,10 +3642,56 @@ func TestUpdateCRLShard(t *testing.T) {
 	test.AssertError(t, err, "updating an unknown shard")
 }
 
+func TestReplacementOrderExistsGracePeriod(t *testing.T) {
+	if os.Getenv("BOULDER_CONFIG_DIR") != "test/config-next" {
+		t.Skip("TestReplacementOrderExistsGracePeriod requires config-next")
+	}
+
+	features.Set(features.Config{StoreReplacementFinalizedTime: true})
+	defer features.Reset()
+
+	sa, fc := initSA(t)
+	sa.replacementGracePeriod = time.Hour
+
+	oldCertSerial := "1234567890"
+	insertCertificateStatus(t, sa.dbMap, oldCertSerial, core.OCSPStatusGood, fc.Now().Add(90*24*time.Hour))
+	reg := createWorkingRegistration(t, sa)
+	authzID := createFinalizedAuthorization(t, sa, reg.Id, identifier.NewDNS("example.com"), fc.Now().Add(time.Hour), "valid", fc.Now())
+
+	// Create and finalize a replacement order.
+	order, err := sa.NewOrderAndAuthzs(ctx, &sapb.NewOrderAndAuthzsRequest{
+		NewOrder: &sapb.NewOrderRequest{
+			RegistrationID:   reg.Id,
+			Expires:          timestamppb.New(fc.Now().Add(24 * time.Hour)),
+			Identifiers:      []*corepb.Identifier{identifier.NewDNS("example.com").ToProto()},
+			V2Authorizations: []int64{authzID},
+			ReplacesSerial:   oldCertSerial,
+		},
+	})
+	test.AssertNotError(t, err, "NewOrderAndAuthzs failed")
+	_, err = sa.SetOrderProcessing(ctx, &sapb.OrderRequest{Id: order.Id})
+	test.AssertNotError(t, err, "SetOrderProcessing failed")
+	_, err = sa.FinalizeOrder(ctx, &sapb.FinalizeOrderRequest{Id: order.Id, CertificateSerial: "0123456789"})
+	test.AssertNotError(t, err, "FinalizeOrder failed")
+
+	// Within the grace period, the certificate may be replaced again.
+	fc.Add(30 * time.Minute)
+	exists, err := sa.ReplacementOrderExists(ctx, &sapb.Serial{Serial: oldCertSerial})
+	test.AssertNotError(t, err, "failed to check for replacement order")
+	test.Assert(t, !exists.Exists, "replacement within grace period should be allowed")
+
+	// After the grace period, it may not.
+	fc.Add(time.Hour)
+	exists, err = sa.ReplacementOrderExists(ctx, &sapb.Serial{Serial: oldCertSerial})
+	test.AssertNotError(t, err, "failed to check for replacement order")
+	test.Assert(t, exists.Exists, "replacement after grace period should be rejected")
+}
+
 func TestReplacementOrderExists(t *testing.T) {
 	sa, fc := initSA(t)
 
 	oldCertSerial := "1234567890"
+	insertCertificateStatus(t, sa.dbMap, oldCertSerial, core.OCSPStatusGood, fc.Now().Add(90*24*time.Hour))
 
 	// Check that a non-existent replacement order does not exist.
 	exists, err := sa.ReplacementOrderExists(ctx, &sapb.Serial{Serial: oldCertSerial})
@@ -4624,6 +4759,205 @@ func TestGetEnabledRateLimitOverrides(t *testing.T) {
 	test.AssertEquals(t, stream.sent[0].Override.BucketKey, "on")
 }
 
+func TestSelectRateLimitOverrides(t *testing.T) {
+	if os.Getenv("BOULDER_CONFIG_DIR") != "test/config-next" {
+		// TODO(#8147): Remove this skip.
+		t.Skip("skipping, this overrides table must exist for this test to run")
+	}
+
+	sa, fc := initSA(t)
+
+	start := fc.Now()
+	insert := func(bucketKey string, updatedAt time.Time, enabled bool) {
+		t.Helper()
+		err := sa.dbMap.Insert(ctx, &overrideModel{
+			LimitEnum: 1,
+			BucketKey: bucketKey,
+			Comment:   bucketKey,
+			PeriodNS:  time.Hour.Nanoseconds(),
+			Count:     10,
+			Burst:     10,
+			UpdatedAt: updatedAt,
+			Enabled:   enabled,
+		})
+		test.AssertNotError(t, err, "inserting override")
+	}
+	insert("old", start.Add(-time.Hour), true)
+	insert("first", start.Add(time.Minute), true)
+	insert("second", start.Add(2*time.Minute), true)
+	insert("disabled", start.Add(3*time.Minute), false)
+
+	_, err := SelectRateLimitOverrides(ctx, sa.dbMap, start, 0)
+	test.AssertError(t, err, "expected error for zero limit")
+
+	overrides, err := SelectRateLimitOverrides(ctx, sa.dbMap, start, 10)
+	test.AssertNotError(t, err, "SelectRateLimitOverrides failed")
+	test.AssertEquals(t, len(overrides), 2)
+	test.AssertEquals(t, overrides[0].BucketKey, "first")
+	test.AssertEquals(t, overrides[1].BucketKey, "second")
+	test.AssertEquals(t, overrides[0].Period.AsDuration(), time.Hour)
+
+	// Syncing from the most recent update seen should return only later rows.
+	overrides, err = SelectRateLimitOverrides(ctx, sa.dbMap, start.Add(time.Minute), 10)
+	test.AssertNotError(t, err, "SelectRateLimitOverrides failed")
+	test.AssertEquals(t, len(overrides), 1)
+	test.AssertEquals(t, overrides[0].BucketKey, "second")
+
+	overrides, err = SelectRateLimitOverrides(ctx, sa.dbMap, start, 1)
+	test.AssertNotError(t, err, "SelectRateLimitOverrides failed")
+	test.AssertEquals(t, len(overrides), 1)
+	test.AssertEquals(t, overrides[0].BucketKey, "first")
+}
+
+func TestDumpRateLimitOverrides(t *testing.T) {
+	if os.Getenv("BOULDER_CONFIG_DIR") != "test/config-next" {
+		// TODO(#8147): Remove this skip.
+		t.Skip("skipping, this overrides table must exist for this test to run")
+	}
+
+	sa, fc := initSA(t)
+
+	start := fc.Now().UTC().Truncate(time.Second)
+	insert := func(limitEnum int64, bucketKey string, updatedAt time.Time, enabled bool) {
+		t.Helper()
+		err := sa.dbMap.Insert(ctx, &overrideModel{
+			LimitEnum: limitEnum,
+			BucketKey: bucketKey,
+			Comment:   bucketKey,
+			PeriodNS:  time.Hour.Nanoseconds(),
+			Count:     10,
+			Burst:     20,
+			UpdatedAt: updatedAt,
+			Enabled:   enabled,
+		})
+		test.AssertNotError(t, err, "inserting override")
+	}
+	insert(1, "b", start.Add(time.Minute), true)
+	insert(1, "a", start, true)
+	insert(2, "c", start.Add(2*time.Minute), true)
+	insert(1, "disabled", start.Add(3*time.Minute), false)
+
+	var buf bytes.Buffer
+	err := DumpRateLimitOverrides(ctx, sa.dbMap, &buf, false)
+	test.AssertNotError(t, err, "DumpRateLimitOverrides failed")
+	test.AssertEquals(t, buf.String(), "limitEnum,bucketKey,count,burst,period,comment,updatedAt\n"+
+		"1,a,10,20,1h0m0s,a,"+start.Format(time.RFC3339)+"\n"+
+		"1,b,10,20,1h0m0s,b,"+start.Add(time.Minute).Format(time.RFC3339)+"\n"+
+		"2,c,10,20,1h0m0s,c,"+start.Add(2*time.Minute).Format(time.RFC3339)+"\n")
+
+	// Sorting by updatedAt puts the most recently updated first.
+	buf.Reset()
+	err = DumpRateLimitOverrides(ctx, sa.dbMap, &buf, true)
+	test.AssertNotError(t, err, "DumpRateLimitOverrides failed")
+	test.AssertEquals(t, buf.String(), "limitEnum,bucketKey,count,burst,period,comment,updatedAt\n"+
+		"2,c,10,20,1h0m0s,c,"+start.Add(2*time.Minute).Format(time.RFC3339)+"\n"+
+		"1,b,10,20,1h0m0s,b,"+start.Add(time.Minute).Format(time.RFC3339)+"\n"+
+		"1,a,10,20,1h0m0s,a,"+start.Format(time.RFC3339)+"\n")
+}
+
+func TestUpsertRateLimitOverride(t *testing.T) {
+	if os.Getenv("BOULDER_CONFIG_DIR") != "test/config-next" {
+		// TODO(#8147): Remove this skip.
+		t.Skip("skipping, this overrides table must exist for this test to run")
+	}
+
+	sa, fc := initSA(t)
+
+	ov := &sapb.RateLimitOverride{
+		LimitEnum: 1,
+		BucketKey: core.RandomString(10),
+		Comment:   "insert",
+		Period:    durationpb.New(time.Hour),
+		Count:     100,
+		Burst:     100,
+	}
+
+	// Insert
+	err := UpsertRateLimitOverride(ctx, sa.dbMap, ov, true, fc.Now())
+	test.AssertNotError(t, err, "expected successful insert, got error")
+
+	got, err := sa.GetRateLimitOverride(ctx, &sapb.GetRateLimitOverrideRequest{LimitEnum: ov.LimitEnum, BucketKey: ov.BucketKey})
+	test.AssertNotError(t, err, "expected GetRateLimitOverride to succeed, got error")
+	test.AssertEquals(t, got.Override.Count, int64(100))
+	test.AssertEquals(t, got.Override.Burst, int64(100))
+	test.Assert(t, got.Enabled, "expected inserted override to be enabled")
+
+	// Update, lowering count and burst and disabling.
+	fc.Add(time.Minute)
+	ov.Comment = "update"
+	ov.Count = 10
+	ov.Burst = 20
+	err = UpsertRateLimitOverride(ctx, sa.dbMap, ov, false, fc.Now())
+	test.AssertNotError(t, err, "expected successful update, got error")
+
+	got, err = sa.GetRateLimitOverride(ctx, &sapb.GetRateLimitOverrideRequest{LimitEnum: ov.LimitEnum, BucketKey: ov.BucketKey})
+	test.AssertNotError(t, err, "expected GetRateLimitOverride to succeed, got error")
+	test.AssertEquals(t, got.Override.Comment, "update")
+	test.AssertEquals(t, got.Override.Count, int64(10))
+	test.AssertEquals(t, got.Override.Burst, int64(20))
+	test.Assert(t, !got.Enabled, "expected updated override to be disabled")
+	test.AssertEquals(t, got.UpdatedAt.AsTime(), fc.Now())
+
+	var count int
+	err = sa.dbMap.SelectOne(ctx, &count, "SELECT COUNT(*) FROM overrides WHERE limitEnum = ? AND bucketKey = ?", ov.LimitEnum, ov.BucketKey)
+	test.AssertNotError(t, err, "counting overrides")
+	test.AssertEquals(t, count, 1)
+}
+
+func TestSetRateLimitOverrideEnabled(t *testing.T) {
+	if os.Getenv("BOULDER_CONFIG_DIR") != "test/config-next" {
+		// TODO(#8147): Remove this skip.
+		t.Skip("skipping, this overrides table must exist for this test to run")
+	}
+
+	sa, fc := initSA(t)
+
+	ov := &sapb.RateLimitOverride{
+		LimitEnum: 1,
+		BucketKey: core.RandomString(10),
+		Comment:   "toggle",
+		Period:    durationpb.New(time.Hour),
+		Count:     100,
+		Burst:     100,
+	}
+	err := UpsertRateLimitOverride(ctx, sa.dbMap, ov, true, fc.Now())
+	test.AssertNotError(t, err, "expected successful insert, got error")
+
+	// Disable
+	fc.Add(time.Minute)
+	changed, err := SetRateLimitOverrideEnabled(ctx, sa.dbMap, ov.LimitEnum, ov.BucketKey, false, fc.Now())
+	test.AssertNotError(t, err, "expected SetRateLimitOverrideEnabled to succeed, got error")
+	test.Assert(t, changed, "expected disabling an enabled override to change a row")
+
+	got, err := sa.GetRateLimitOverride(ctx, &sapb.GetRateLimitOverrideRequest{LimitEnum: ov.LimitEnum, BucketKey: ov.BucketKey})
+	test.AssertNotError(t, err, "expected GetRateLimitOverride to succeed, got error")
+	test.Assert(t, !got.Enabled, "expected override to be disabled")
+	test.AssertEquals(t, got.UpdatedAt.AsTime(), fc.Now())
+
+	// Disabling again is a no-op and doesn't advance updatedAt.
+	disabledAt := fc.Now()
+	fc.Add(time.Minute)
+	changed, err = SetRateLimitOverrideEnabled(ctx, sa.dbMap, ov.LimitEnum, ov.BucketKey, false, fc.Now())
+	test.AssertNotError(t, err, "expected SetRateLimitOverrideEnabled to succeed, got error")
+	test.Assert(t, !changed, "expected disabling a disabled override not to change a row")
+
+	// Re-enable
+	fc.Add(time.Minute)
+	changed, err = SetRateLimitOverrideEnabled(ctx, sa.dbMap, ov.LimitEnum, ov.BucketKey, true, fc.Now())
+	test.AssertNotError(t, err, "expected SetRateLimitOverrideEnabled to succeed, got error")
+	test.Assert(t, changed, "expected enabling a disabled override to change a row")
+
+	got, err = sa.GetRateLimitOverride(ctx, &sapb.GetRateLimitOverrideRequest{LimitEnum: ov.LimitEnum, BucketKey: ov.BucketKey})
+	test.AssertNotError(t, err, "expected GetRateLimitOverride to succeed, got error")
+	test.Assert(t, got.Enabled, "expected override to be enabled")
+	test.Assert(t, got.UpdatedAt.AsTime().After(disabledAt), "expected updatedAt to advance")
+
+	// A nonexistent override is not changed.
+	changed, err = SetRateLimitOverrideEnabled(ctx, sa.dbMap, ov.LimitEnum, "nonexistent", false, fc.Now())
+	test.AssertNotError(t, err, "expected SetRateLimitOverrideEnabled to succeed, got error")
+	test.Assert(t, !changed, "expected no row to change for a nonexistent override")
+}
+
 func TestOverrideLowerThanExisting(t *testing.T) {
 	t.Parallel()
 
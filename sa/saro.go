@@ -47,6 +47,12 @@ type SQLStorageAuthorityRO struct {
 	// as, the observed database replication lag.
 	lagFactor time.Duration
 
+	// replacementGracePeriod is how long after a certificate is first replaced
+	// that a new replacement order for it will still be accepted. This allows
+	// a Subscriber whose deployment of the replacement failed to replace the
+	// certificate again. If zero, a certificate can only be replaced once.
+	replacementGracePeriod time.Duration
+
 	clk clock.Clock
 	log blog.Logger
 
@@ -67,6 +73,7 @@ func NewSQLStorageAuthorityRO(
 	dbIncidentsMap *db.WrappedMap,
 	stats prometheus.Registerer,
 	lagFactor time.Duration,
+	replacementGracePeriod time.Duration,
 	clk clock.Clock,
 	logger blog.Logger,
 ) (*SQLStorageAuthorityRO, error) {
@@ -76,12 +83,13 @@ func NewSQLStorageAuthorityRO(
 	}, []string{"method", "result"})
 
 	ssaro := &SQLStorageAuthorityRO{
-		dbReadOnlyMap:    dbReadOnlyMap,
-		dbIncidentsMap:   dbIncidentsMap,
-		lagFactor:        lagFactor,
-		clk:              clk,
-		log:              logger,
-		lagFactorCounter: lagFactorCounter,
+		dbReadOnlyMap:          dbReadOnlyMap,
+		dbIncidentsMap:         dbIncidentsMap,
+		lagFactor:              lagFactor,
+		replacementGracePeriod: replacementGracePeriod,
+		clk:                    clk,
+		log:                    logger,
+		lagFactorCounter:       lagFactorCounter,
 	}
 
 	return ssaro, nil
@@ -398,9 +406,9 @@ func (ssa *SQLStorageAuthorityRO) GetOrder(ctx context.Context, req *sapb.OrderR
 }
 
 // GetOrderForNames tries to find a **pending** or **ready** order with the
-// exact set of names requested, associated with the given accountID. Only
-// unexpired orders are considered. If no order meeting these requirements is
-// found a nil corepb.Order pointer is returned.
+// exact set of names requested, associated with the given accountID and
+// certificate profile name. Only unexpired orders are considered. If no order
+// meeting these requirements is found a nil corepb.Order pointer is returned.
 func (ssa *SQLStorageAuthorityRO) GetOrderForNames(ctx context.Context, req *sapb.GetOrderForNamesRequest) (*corepb.Order, error) {
 	idents := identifier.FromProtoSlice(req.Identifiers)
 
@@ -415,8 +423,8 @@ func (ssa *SQLStorageAuthorityRO) GetOrderForNames(ctx context.Context, req *sap
 	// status in this query because there's no index that includes those, so
 	// including them could require the DB to scan extra rows.
 	// Instead, we select one unexpired order that matches the fqdnSet. If
-	// that order doesn't match the account ID or status we need, just return
-	// nothing. We use `ORDER BY expires ASC` because the index on
+	// that order doesn't match the account ID, status, or profile we need, just
+	// return nothing. We use `ORDER BY expires ASC` because the index on
 	// (setHash, expires) is in ASC order. DESC would be slightly nicer from a
 	// user experience perspective but would be slow when there are many entries
 	// to sort.
@@ -457,6 +465,11 @@ func (ssa *SQLStorageAuthorityRO) GetOrderForNames(ctx context.Context, req *sap
 		order.Status != string(core.StatusReady) {
 		return nil, berrors.NotFoundError("no order matching request found")
 	}
+	// Only return an order with the same profile. Orders without a profile
+	// have an empty profile name, so they only match requests without one.
+	if order.CertificateProfileName != req.CertificateProfileName {
+		return nil, berrors.NotFoundError("no order matching request found")
+	}
 	return order, nil
 }
 
@@ -599,7 +612,10 @@ func (ssa *SQLStorageAuthorityRO) GetValidAuthorizations2(ctx context.Context, r
 	// The WHERE clause returned by this function does not contain any
 	// user-controlled strings; all user-controlled input ends up in the
 	// returned placeholder args.
-	identConditions, identArgs := buildIdentifierQueryConditions(idents)
+	identConditions, identArgs, err := buildIdentifierQueryConditions(idents)
+	if err != nil {
+		return nil, err
+	}
 	query := fmt.Sprintf(
 		`SELECT %s FROM authz2
 			USE INDEX (regID_identifier_status_expires_idx)
@@ -619,7 +635,7 @@ func (ssa *SQLStorageAuthorityRO) GetValidAuthorizations2(ctx context.Context, r
 	params = append(params, identArgs...)
 
 	var authzModels []authzModel
-	_, err := ssa.dbReadOnlyMap.Select(
+	_, err = ssa.dbReadOnlyMap.Select(
 		ctx,
 		&authzModels,
 		query,
@@ -866,7 +882,7 @@ func (ssa *SQLStorageAuthorityRO) ReplacementOrderExists(ctx context.Context, re
 		}
 		return nil, err
 	}
-	if replacement.Replaced {
+	if replacement.Replaced && !withinReplacementGrace(replacement.ReplacedAt, ssa.clk.Now(), ssa.replacementGracePeriod) {
 		// Certificate has already been replaced.
 		return &sapb.Exists{Exists: true}, nil
 	}
@@ -886,6 +902,12 @@ func (ssa *SQLStorageAuthorityRO) ReplacementOrderExists(ctx context.Context, re
 		}
 	}
 
+	if replacement.Replaced && replacementOrder.Status == string(core.StatusValid) {
+		// The certificate was replaced, but recently enough that it may be
+		// replaced again.
+		return &sapb.Exists{Exists: false}, nil
+	}
+
 	switch replacementOrder.Status {
 	case string(core.StatusPending), string(core.StatusReady), string(core.StatusProcessing), string(core.StatusValid):
 		// An existing replacement order is either still being worked on or has
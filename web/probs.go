@@ -56,6 +56,10 @@ func problemDetailsForBoulderError(err *berrors.BoulderError, msg string) *probs
 		outProb = probs.BadSignatureAlgorithm(fmt.Sprintf("%s :: %s", msg, err))
 	case berrors.AccountDoesNotExist:
 		outProb = probs.AccountDoesNotExist(fmt.Sprintf("%s :: %s", msg, err))
+	case berrors.AccountDeactivated:
+		// RFC 8555, Section 7.3.6: requests from a deactivated account are
+		// rejected with an "unauthorized" problem.
+		outProb = probs.Unauthorized(fmt.Sprintf("%s :: %s", msg, err))
 	case berrors.BadNonce:
 		// We stuff extra internal info into bad nonce errors, but none of it is
 		// really actionable by the end-user, so overwrite the user-visible message.
@@ -2134,7 +2134,7 @@ func (wfe *WebFrontEndImpl) orderToOrderJSON(request *http.Request, order *corep
 // created, the func will be nil if any error was encountered during the check.
 //
 // Precondition: idents must be a list of identifiers that all pass
-// policy.WellFormedIdentifiers.
+// policy.WellFormedIdentifiersLenient.
 func (wfe *WebFrontEndImpl) checkNewOrderLimits(ctx context.Context, regId int64, idents identifier.ACMEIdentifiers, isRenewal bool) (func(), error) {
 	txns, err := wfe.txnBuilder.NewOrderLimitTransactions(regId, idents, isRenewal)
 	if err != nil {
@@ -2415,7 +2415,9 @@ func (wfe *WebFrontEndImpl) NewOrder(
 	idents = identifier.Normalize(idents)
 	logEvent.Identifiers = idents
 
-	err = policy.WellFormedIdentifiers(idents)
+	// The RA's policy authority enforces the configured limits on DNS
+	// identifiers, which may be more permissive than the defaults.
+	err = policy.WellFormedIdentifiersLenient(idents)
 	if err != nil {
 		wfe.sendError(response, logEvent, web.ProblemDetailsForError(err, "Invalid identifiers requested"), nil)
 		return
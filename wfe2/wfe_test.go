@@ -412,7 +412,7 @@ func setupWFE(t *testing.T) (WebFrontEndImpl, clock.FakeClock, requestSigner) {
 	// Setup rate limiting.
 	limiter, err := ratelimits.NewLimiter(fc, ratelimits.NewInmemSource(), stats)
 	test.AssertNotError(t, err, "making limiter")
-	txnBuilder, err := ratelimits.NewTransactionBuilderFromFiles("../test/config-next/ratelimit-defaults.yml", "", stats, logger)
+	txnBuilder, err := ratelimits.NewTransactionBuilderFromFiles("../test/config-next/ratelimit-defaults.yml", false, ratelimits.RoundTruncate, "", false, stats, logger)
 	test.AssertNotError(t, err, "making transaction composer")
 
 	unpauseSigner, err := unpause.NewJWTSigner(cmd.HMACKeyConfig{KeyFile: "../test/secrets/sfe_unpause_key"})
@@ -2935,6 +2935,38 @@ func TestNewOrder(t *testing.T) {
 						"finalize": "http://localhost/acme/finalize/1/1"
 					}`,
 		},
+		{
+			Name:    "POST, good payload, label limit left to the RA",
+			Request: signAndPost(signer, targetPath, signedURL, `{"identifiers":[{"type":"dns","value":"a.b.c.d.e.f.g.h.i.not-example.com"}]}`),
+			ExpectedBody: `
+					{
+						"status": "pending",
+						"expires": "2021-02-01T01:01:01Z",
+						"identifiers": [
+							{ "type": "dns", "value": "a.b.c.d.e.f.g.h.i.not-example.com"}
+						],
+						"authorizations": [
+							"http://localhost/acme/authz/1/1"
+						],
+						"finalize": "http://localhost/acme/finalize/1/1"
+					}`,
+		},
+		{
+			Name:    "POST, good payload, leading underscore label left to the RA",
+			Request: signAndPost(signer, targetPath, signedURL, `{"identifiers":[{"type":"dns","value":"_dmarc.not-example.com"}]}`),
+			ExpectedBody: `
+					{
+						"status": "pending",
+						"expires": "2021-02-01T01:01:01Z",
+						"identifiers": [
+							{ "type": "dns", "value": "_dmarc.not-example.com"}
+						],
+						"authorizations": [
+							"http://localhost/acme/authz/1/1"
+						],
+						"finalize": "http://localhost/acme/finalize/1/1"
+					}`,
+		},
 		{
 			Name:    "POST, good payload, but when the input had mixed case",
 			Request: signAndPost(signer, targetPath, signedURL, validOrderBodyWithMixedCaseIdentifiers),
@@ -4286,7 +4318,7 @@ func TestNewOrderRateLimits(t *testing.T) {
 			Burst:  1,
 			Count:  1,
 			Period: config.Duration{Duration: time.Hour * 24}},
-	}, nil, metrics.NoopRegisterer, blog.NewMock())
+	}, ratelimits.RoundTruncate, nil, metrics.NoopRegisterer, blog.NewMock())
 	test.AssertNotError(t, err, "making transaction composer")
 	wfe.txnBuilder = txnBuilder
 